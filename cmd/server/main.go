@@ -1,17 +1,28 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/api/handlers"
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/api/routes"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/audio"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/auth"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/bilibili"
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/cache"
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/config"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/prewarm"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/storage"
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/driver/sqlite"
@@ -20,12 +31,21 @@ import (
 )
 
 func main() {
+	// 命令行参数
+	reconcileOnly := flag.Bool("reconcile", false, "对账缓存 (数据库记录、JSON条目与音频文件) 后退出")
+	flag.Parse()
+
 	// 初始化配置
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.Fatal("Failed to load config:", err)
 	}
 
+	// 校验转码配置
+	if _, err := audio.NewProfiles(cfg.Transcode); err != nil {
+		log.Fatal("Invalid transcode profiles:", err)
+	}
+
 	// 根据配置创建必要的目录和文件
 	if err := createRequiredDirectories(cfg); err != nil {
 		log.Fatal("Failed to create required directories:", err)
@@ -47,8 +67,59 @@ func main() {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
+	// 初始化存储后端
+	store, err := storage.New(cfg.Storage, cfg.Cache.Dir)
+	if err != nil {
+		log.Fatal("Failed to initialize storage:", err)
+	}
+	if !store.Exclusive() {
+		logrus.Warn("Storage is not exclusive to this instance (storage.s3.replica is empty), orphan cleanup is disabled; set a distinct replica on every instance sharing the bucket")
+	}
+
 	// 初始化并启动缓存清理工作器
-	cacheManager := cache.NewManager(cfg.Cache.Dir, cfg.Cache.TTL, db)
+	cacheManager := cache.NewManager(cfg.Cache, db, store)
+
+	// -reconcile: 对账后输出结果并退出，可在服务运行时执行 (不清理临时文件)
+	if *reconcileOnly {
+		report, err := cacheManager.Reconcile()
+		if err != nil {
+			log.Fatal("Failed to reconcile cache:", err)
+		}
+		output, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(output))
+		return
+	}
+
+	// 子命令: 执行后输出结果并退出，可在服务运行时执行
+	switch flag.Arg(0) {
+	case "prewarm":
+		runPrewarm(cfg, db, cacheManager, flag.Args()[1:])
+		return
+	case "pin", "unpin":
+		runPin(cacheManager, flag.Arg(0) == "pin", flag.Args()[1:])
+		return
+	case "apikey":
+		runAPIKey(auth.NewAuthenticator(cfg.Auth.Tokens, db), flag.Args()[1:])
+		return
+	}
+
+	if err := cacheManager.SweepTemp(); err != nil {
+		logrus.Warnf("Failed to sweep stale temp files: %v", err)
+	}
+
+	// 将旧版本缓存键的条目迁移到当前版本，需在对账之前完成
+	if _, err := cacheManager.MigrateKeys(); err != nil {
+		logrus.Warnf("Failed to migrate cache keys: %v", err)
+	}
+
+	// 启动时对账缓存，并定期重复
+	if report, err := cacheManager.Reconcile(); err != nil {
+		logrus.Warnf("Failed to reconcile cache: %v", err)
+	} else {
+		logrus.Infof("Cache reconciled: %s", report)
+	}
+	cacheManager.StartReconcileWorker(cfg.Cache.ReconcileInterval)
+
 	cacheManager.StartCleanupWorker(cfg.Cache.CleanupInterval)
 	if cfg.Cache.CleanupInterval.IsNever {
 		logrus.Info("Cache cleanup disabled (set to 'never')")
@@ -62,8 +133,15 @@ func main() {
 		logrus.Infof("Log cleanup worker started, will clean logs older than %d days", cfg.Logging.MaxAge)
 	}
 
+	if cfg.Auth.Enabled {
+		logrus.Infof("Token authentication enabled with %d static tokens", len(cfg.Auth.Tokens))
+	}
+
 	// 初始化路由
-	router := routes.SetupRouter(cfg, db)
+	router, err := routes.SetupRouter(cfg, db, cacheManager)
+	if err != nil {
+		log.Fatal("Failed to setup router:", err)
+	}
 
 	// 启动服务器
 	addr := cfg.Server.Host + ":" + cfg.Server.Port
@@ -74,6 +152,146 @@ func main() {
 	}
 }
 
+// runPrewarm 预热缓存，参数可以是BV号、页面地址 (sitemap/RSS/HTML) 或包含视频链接的本地文件
+func runPrewarm(cfg *config.Config, db *gorm.DB, cacheManager *cache.Manager, args []string) {
+	flags := flag.NewFlagSet("prewarm", flag.ExitOnError)
+	quality := flags.Int("quality", 0, "音质")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		log.Fatal("Usage: server prewarm [-quality N] <BV号|URL|文件>...")
+	}
+
+	discoverer := prewarm.NewDiscoverer(cfg.Bilibili.UserAgent, cfg.Prewarm.MaxPages)
+	var request prewarm.Request
+	for _, arg := range flags.Args() {
+		switch {
+		case utils.IsValidBVID(arg):
+			request.BVIDs = append(request.BVIDs, arg)
+		case strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://"):
+			request.URLs = append(request.URLs, arg)
+		default:
+			content, err := os.ReadFile(arg)
+			if err != nil {
+				log.Fatal("Failed to read prewarm source:", err)
+			}
+			bvids, err := discoverer.Discover(content)
+			if err != nil {
+				logrus.Warnf("Failed to discover videos in %s: %v", arg, err)
+			}
+			request.BVIDs = append(request.BVIDs, bvids...)
+		}
+	}
+
+	parser := bilibili.NewAudioParser(
+		cfg.Bilibili.UserAgent,
+		cfg.Bilibili.Referer,
+		cfg.Cache.Dir,
+		cacheManager.Storage(),
+	)
+	parseHandler, err := handlers.NewParseHandler(parser, cfg.Transcode, cacheManager, db)
+	if err != nil {
+		log.Fatal("Failed to create parse handler:", err)
+	}
+	warmer := prewarm.NewWarmer(discoverer, cfg.Prewarm.Delay, 1)
+	task := warmer.NewTask(request, func(bvid string) (bool, error) {
+		cached, err := parseHandler.Prewarm(bvid, *quality)
+		switch {
+		case err != nil:
+			fmt.Printf("%s failed: %v\n", bvid, err)
+		case cached:
+			fmt.Printf("%s cached\n", bvid)
+		default:
+			fmt.Printf("%s parsed\n", bvid)
+		}
+		return cached, err
+	})
+	warmer.Run(task)
+
+	output, _ := json.MarshalIndent(task, "", "  ")
+	fmt.Println(string(output))
+}
+
+// runPin 固定或取消固定缓存条目，参数为资源标识 (BV号、au号等) 或缓存键，pin不带参数时列出固定的条目
+func runPin(cacheManager *cache.Manager, pinned bool, args []string) {
+	var records []models.CacheRecord
+	if len(args) == 0 {
+		if !pinned {
+			log.Fatal("Usage: server unpin <BV号|缓存键>...")
+		}
+		list, err := cacheManager.Pinned()
+		if err != nil {
+			log.Fatal("Failed to list pinned cache entries:", err)
+		}
+		records = list
+	}
+
+	for _, arg := range args {
+		bvid, key := arg, ""
+		if cache.IsKey(arg) {
+			bvid, key = "", arg
+		}
+		updated, err := cacheManager.SetPinned(bvid, key, pinned)
+		if err != nil {
+			log.Fatal("Failed to update cache entry:", err)
+		}
+		if len(updated) == 0 {
+			logrus.Warnf("No cache entry found for %s", arg)
+		}
+		records = append(records, updated...)
+	}
+
+	output, _ := json.MarshalIndent(records, "", "  ")
+	fmt.Println(string(output))
+}
+
+// runAPIKey 管理访问密钥: create <名称> 创建 (密钥只输出这一次)，list 列出，revoke <ID> 吊销
+func runAPIKey(authenticator *auth.Authenticator, args []string) {
+	usage := "Usage: server apikey create <名称> | list | revoke <ID>"
+	if len(args) == 0 {
+		log.Fatal(usage)
+	}
+
+	var result interface{}
+	switch args[0] {
+	case "create":
+		if len(args) != 2 || args[1] == "" {
+			log.Fatal(usage)
+		}
+		plain, key, err := authenticator.CreateKey(args[1])
+		if err != nil {
+			log.Fatal("Failed to create api key:", err)
+		}
+		result = map[string]interface{}{"key": plain, "record": key}
+	case "list":
+		keys, err := authenticator.ListKeys()
+		if err != nil {
+			log.Fatal("Failed to list api keys:", err)
+		}
+		result = keys
+	case "revoke":
+		if len(args) != 2 {
+			log.Fatal(usage)
+		}
+		id, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			log.Fatal("Invalid api key id:", args[1])
+		}
+		key, err := authenticator.RevokeKey(uint(id))
+		if err != nil {
+			log.Fatal("Failed to revoke api key:", err)
+		}
+		if key == nil {
+			log.Fatal("No api key found with id ", id)
+		}
+		result = key
+	default:
+		log.Fatal(usage)
+	}
+
+	output, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(output))
+}
+
 func initDatabase(cfg *config.Config) (*gorm.DB, error) {
 	var db *gorm.DB
 	var err error
@@ -101,7 +319,9 @@ func initDatabase(cfg *config.Config) (*gorm.DB, error) {
 func autoMigrate(db *gorm.DB) error {
 	return db.AutoMigrate(
 		&models.CacheRecord{},
+		&models.AudioFile{},
 		&models.RequestLog{},
+		&models.APIKey{},
 	)
 }
 
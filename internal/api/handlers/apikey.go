@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/auth"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	auth *auth.Authenticator
+}
+
+func NewAPIKeyHandler(authenticator *auth.Authenticator) *APIKeyHandler {
+	return &APIKeyHandler{
+		auth: authenticator,
+	}
+}
+
+// CreateAPIKeyRequest 创建访问密钥请求结构
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" binding:"required,max=100"` // 用途说明，如调用方名称
+}
+
+// ListKeys 列出全部访问密钥 (不含密钥内容)
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	keys, err := h.auth.ListKeys()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"keys": keys,
+	})
+}
+
+// CreateKey 创建访问密钥，密钥内容只在本次响应中返回
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	plain, key, err := h.auth.CreateKey(req.Name)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "创建失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"key":    plain,
+		"record": key,
+	})
+}
+
+// RevokeKey 吊销访问密钥，立即生效
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的密钥ID")
+		return
+	}
+
+	key, err := h.auth.RevokeKey(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "吊销失败: "+err.Error())
+		return
+	}
+	if key == nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "未找到访问密钥")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"record": key,
+	})
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/cache"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CacheAdminHandler struct {
+	cache *cache.Manager
+}
+
+func NewCacheAdminHandler(cacheManager *cache.Manager) *CacheAdminHandler {
+	return &CacheAdminHandler{
+		cache: cacheManager,
+	}
+}
+
+// PinRequest 固定/取消固定请求结构，bvid与key二选一
+type PinRequest struct {
+	BVID string `form:"bvid"` // 资源标识 (BV号、au号等)，匹配该资源的全部音质与变体
+	Key  string `form:"key"`  // 缓存键，匹配单个条目
+}
+
+// ListPins 列出全部固定的缓存条目
+func (h *CacheAdminHandler) ListPins(c *gin.Context) {
+	records, err := h.cache.Pinned()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"records": records,
+	})
+}
+
+// Pin 固定缓存条目，固定的条目不会过期也不会被淘汰
+func (h *CacheAdminHandler) Pin(c *gin.Context) {
+	h.setPinned(c, true)
+}
+
+// Unpin 取消固定，条目从当前时间重新计算过期时间
+func (h *CacheAdminHandler) Unpin(c *gin.Context) {
+	h.setPinned(c, false)
+}
+
+func (h *CacheAdminHandler) setPinned(c *gin.Context, pinned bool) {
+	var req PinRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+	if req.BVID == "" && req.Key == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: 需要bvid或key")
+		return
+	}
+
+	records, err := h.cache.SetPinned(req.BVID, req.Key, pinned)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "操作失败: "+err.Error())
+		return
+	}
+	if len(records) == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, "未找到缓存条目")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"records": records,
+	})
+}
+
+// CacheListRequest 缓存列表请求结构
+type CacheListRequest struct {
+	BVID     string `form:"bvid"`      // 资源标识 (可选)
+	Quality  *int   `form:"quality"`   // 音质 (可选)
+	Pinned   *bool  `form:"pinned"`    // 是否固定 (可选)
+	Expired  *bool  `form:"expired"`   // 是否已过期 (可选)
+	Sort     string `form:"sort"`      // 排序 recent / hits / created / expires (可选，默认recent)
+	Page     int    `form:"page"`      // 页码，从1开始 (可选)
+	PageSize int    `form:"page_size"` // 每页条数 (可选，默认20，最大100)
+}
+
+// ListEntries 按条件分页列出缓存条目
+func (h *CacheAdminHandler) ListEntries(c *gin.Context) {
+	var req CacheListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+	if !cache.ValidSort(req.Sort) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: 不支持的排序方式 "+req.Sort)
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100
+	}
+
+	filter := cache.ListFilter{
+		BVID:    req.BVID,
+		Quality: req.Quality,
+		Pinned:  req.Pinned,
+		Expired: req.Expired,
+		Sort:    req.Sort,
+	}
+	records, total, err := h.cache.List(filter, (req.Page-1)*req.PageSize, req.PageSize)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"records":   records,
+		"total":     total,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+	})
+}
+
+// GetEntry 缓存条目详情，包括占用的文件及大小
+func (h *CacheAdminHandler) GetEntry(c *gin.Context) {
+	key := c.Param("key")
+	if !cache.IsKey(key) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的缓存键")
+		return
+	}
+
+	entry, err := h.cache.Entry(key)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+	if entry == nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "未找到缓存条目")
+		return
+	}
+
+	utils.SuccessResponse(c, entry)
+}
+
+// PurgeRequest 清除缓存请求结构，bvid、key、older_than可组合，all=true时清除全部
+type PurgeRequest struct {
+	BVID          string        `form:"bvid"`           // 资源标识 (可选)
+	Key           string        `form:"key"`            // 缓存键 (可选)
+	OlderThan     time.Duration `form:"older_than"`     // 创建时间超过该时长，如 72h (可选)
+	All           bool          `form:"all"`            // 清除全部条目 (可选)
+	IncludePinned bool          `form:"include_pinned"` // 同时清除固定的条目 (可选)
+}
+
+// Purge 按条件清除缓存条目，固定的条目默认跳过
+func (h *CacheAdminHandler) Purge(c *gin.Context) {
+	var req PurgeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+	if key := c.Param("key"); key != "" {
+		req.Key = key
+	}
+	if !req.All && req.BVID == "" && req.Key == "" && req.OlderThan <= 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: 需要bvid、key、older_than或all=true")
+		return
+	}
+	if req.Key != "" && !cache.IsKey(req.Key) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的缓存键")
+		return
+	}
+
+	report, err := h.cache.Purge(cache.PurgeOptions{
+		BVID:          req.BVID,
+		Key:           req.Key,
+		OlderThan:     req.OlderThan,
+		All:           req.All,
+		IncludePinned: req.IncludePinned,
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "清除失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, report)
+}
+
+// Cleanup 立即清理过期条目，并在超出容量限制时执行淘汰
+func (h *CacheAdminHandler) Cleanup(c *gin.Context) {
+	if err := h.cache.CleanupExpired(); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "清理失败: "+err.Error())
+		return
+	}
+	if err := h.cache.EnforceLimits(); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "淘汰失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"stats": h.cache.Stats(),
+	})
+}
+
+// Reconcile 立即对账数据库记录、JSON条目与文件，返回对账结果
+func (h *CacheAdminHandler) Reconcile(c *gin.Context) {
+	report, err := h.cache.Reconcile()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "对账失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, report)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/live"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LiveHandler struct {
+	manager *live.Manager
+}
+
+func NewLiveHandler(manager *live.Manager) *LiveHandler {
+	return &LiveHandler{
+		manager: manager,
+	}
+}
+
+// StreamAudio 直播间音频转发接口
+func (h *LiveHandler) StreamAudio(c *gin.Context) {
+	roomID, err := strconv.ParseInt(c.Param("roomid"), 10, 64)
+	if err != nil || roomID <= 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的直播间号")
+		return
+	}
+
+	sub, err := h.manager.Subscribe(roomID)
+	if err != nil {
+		switch {
+		case errors.Is(err, live.ErrRoomNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "直播间不存在")
+		case errors.Is(err, live.ErrRoomNotLive):
+			utils.ErrorResponse(c, http.StatusNotFound, "直播间未开播")
+		case errors.Is(err, live.ErrTooManyListeners):
+			utils.ErrorResponse(c, http.StatusServiceUnavailable, "直播间听众已满，请稍后再试")
+		default:
+			utils.ErrorResponse(c, http.StatusBadGateway, "获取直播流失败: "+err.Error())
+		}
+		return
+	}
+	defer sub.Close()
+
+	c.Header("Content-Type", h.manager.ContentType())
+	c.Header("Cache-Control", "no-cache, no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case chunk, ok := <-sub.C():
+			if !ok {
+				return false
+			}
+			_, err := w.Write(chunk)
+			return err == nil
+		}
+	})
+}
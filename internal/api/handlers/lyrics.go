@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LyricsRequest 歌词请求结构
+type LyricsRequest struct {
+	Lang    string `form:"lang" json:"lang"`       // 字幕语言 (可选，默认优先人工字幕)
+	Format  string `form:"format" json:"format"`   // json/lrc/vtt (可选，默认json)
+	Quality int    `form:"quality" json:"quality"` // 音质 (可选)
+	ConvertParams
+}
+
+// GetLyrics 获取视频CC字幕转换的歌词，取自缓存的解析结果，未缓存时先解析音频
+// 歌词文件与音频一同缓存和清理，与 /parse 返回的链接相同
+func (h *ParseHandler) GetLyrics(c *gin.Context) {
+	startTime := time.Now()
+
+	bvid := c.Param("bv")
+	if !utils.IsValidBVID(bvid) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的BV号格式")
+		return
+	}
+
+	var req LyricsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	if req.Format != "" && req.Format != "json" && req.Format != "lrc" && req.Format != "vtt" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: format仅支持json、lrc、vtt")
+		return
+	}
+
+	opts, key, err := req.options(h.transcode, h.profiles)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	audioInfo, err := h.resolveVideo(bvid, req.Quality, opts, key)
+	if err != nil {
+		h.logRequest(c, bvid, req.Quality, http.StatusInternalServerError, err.Error(), startTime)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取歌词失败: "+err.Error())
+		return
+	}
+	h.logRequest(c, bvid, req.Quality, http.StatusOK, "", startTime)
+
+	tracks := audioInfo.Lyrics
+	if tracks == nil {
+		tracks = []models.LyricTrack{}
+	}
+
+	if req.Format == "" || req.Format == "json" {
+		utils.SuccessResponse(c, tracks)
+		return
+	}
+
+	track := selectLyricTrack(tracks, req.Lang)
+	if track == nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "该视频没有可用的字幕")
+		return
+	}
+
+	if req.Format == "lrc" {
+		c.Redirect(http.StatusFound, track.LRCURL)
+	} else {
+		c.Redirect(http.StatusFound, track.VTTURL)
+	}
+}
+
+// selectLyricTrack 选择指定语言的字幕，未指定时优先人工字幕
+func selectLyricTrack(tracks []models.LyricTrack, lang string) *models.LyricTrack {
+	if lang != "" {
+		for i := range tracks {
+			if tracks[i].Lang == lang {
+				return &tracks[i]
+			}
+		}
+		return nil
+	}
+
+	for i := range tracks {
+		if !tracks[i].AI {
+			return &tracks[i]
+		}
+	}
+	if len(tracks) > 0 {
+		return &tracks[0]
+	}
+	return nil
+}
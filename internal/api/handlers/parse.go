@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/audio"
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/bilibili"
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/cache"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/config"
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,24 +18,129 @@ import (
 )
 
 type ParseHandler struct {
-	parser *bilibili.AudioParser
-	cache  *cache.Manager
-	db     *gorm.DB
+	parser    *bilibili.AudioParser
+	cache     *cache.Manager
+	db        *gorm.DB
+	transcode config.TranscodeConfig
+	profiles  map[string]*audio.Profile
 }
 
-func NewParseHandler(userAgent, referer, cacheDir string, cacheManager *cache.Manager, db *gorm.DB) *ParseHandler {
+// NewParseHandler 创建解析处理器，parser与播放列表处理器共用，同一输出文件的转换锁才能生效
+func NewParseHandler(parser *bilibili.AudioParser, transcode config.TranscodeConfig, cacheManager *cache.Manager, db *gorm.DB) (*ParseHandler, error) {
+	profiles, err := audio.NewProfiles(transcode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transcode profiles: %w", err)
+	}
+
 	return &ParseHandler{
-		parser: bilibili.NewAudioParser(userAgent, referer, cacheDir),
-		cache:  cacheManager,
-		db:     db,
+		parser:    parser,
+		cache:     cacheManager,
+		db:        db,
+		transcode: transcode,
+		profiles:  profiles,
+	}, nil
+}
+
+// ConvertParams 各解析接口共用的输出参数
+type ConvertParams struct {
+	Start     string  `form:"start" json:"start"`         // 截取开始时间，秒数或mm:ss (可选)
+	End       string  `form:"end" json:"end"`             // 截取结束时间，秒数或mm:ss (可选)
+	FadeIn    float64 `form:"fade_in" json:"fade_in"`     // 淡入时长(秒) (可选)
+	FadeOut   float64 `form:"fade_out" json:"fade_out"`   // 淡出时长(秒) (可选)
+	Normalize bool    `form:"normalize" json:"normalize"` // EBU R128响度标准化 (可选)
+	HLS       bool    `form:"hls" json:"hls"`             // 同时封装为HLS (可选)
+	Profile   string  `form:"profile" json:"profile"`     // 转码配置名称 (可选)
+}
+
+// options 转换为音频转换选项，同时返回填好输出相关字段的缓存键，资源标识、音质与账号由调用方填写
+func (p ConvertParams) options(transcode config.TranscodeConfig, profiles map[string]*audio.Profile) (audio.ConvertOptions, cache.Key, error) {
+	opts := audio.ConvertOptions{
+		ReplayGain: transcode.Loudness.ReplayGain,
+	}
+	var key cache.Key
+	var extra []string
+
+	profileName := p.Profile
+	if profileName == "" {
+		profileName = transcode.DefaultProfile
 	}
+	if profileName != "" {
+		profile, ok := profiles[profileName]
+		if !ok {
+			return opts, key, fmt.Errorf("unknown profile %q", profileName)
+		}
+		if profile.IsCopy() && (p.Normalize || p.FadeIn > 0 || p.FadeOut > 0) {
+			return opts, key, fmt.Errorf("profile %s does not re-encode, normalize and fades are not supported", profileName)
+		}
+		opts.Profile = profile
+		// 配置内容摘要写入缓存键，修改配置后旧的输出不再命中
+		key.Profile = profile.Tag()
+	}
+
+	if p.Start != "" || p.End != "" || p.FadeIn > 0 || p.FadeOut > 0 {
+		start, err := utils.ParseTimestamp(p.Start)
+		if err != nil {
+			return opts, key, err
+		}
+		end, err := utils.ParseTimestamp(p.End)
+		if err != nil {
+			return opts, key, err
+		}
+
+		clip := &audio.ClipRange{Start: start, End: end, FadeIn: p.FadeIn, FadeOut: p.FadeOut}
+		if err := clip.Validate(); err != nil {
+			return opts, key, err
+		}
+		opts.Clip = clip
+		key.Clip = clip.Tag()
+	}
+
+	if p.Normalize {
+		target := &audio.LoudnessTarget{
+			I:   transcode.Loudness.TargetI,
+			TP:  transcode.Loudness.TargetTP,
+			LRA: transcode.Loudness.TargetLRA,
+		}
+		opts.Normalize = target
+		// 目标值写入缓存键，修改配置后旧的标准化结果不再命中
+		extra = append(extra, fmt.Sprintf("norm=%g/%g/%g", target.I, target.TP, target.LRA))
+	}
+
+	if opts.ReplayGain {
+		// 与输出文件名一致，开关ReplayGain标签后旧的输出不再命中
+		extra = append(extra, "rg")
+	}
+
+	if p.HLS {
+		hls := &audio.HLSOptions{
+			SegmentType:     transcode.HLS.SegmentType,
+			SegmentDuration: transcode.HLS.SegmentDuration,
+			Bitrates:        transcode.HLS.Bitrates,
+		}
+		opts.HLS = hls
+		extra = append(extra, "hls="+hls.Tag())
+	}
+
+	if transcode.Waveform.Enabled {
+		waveform := &audio.WaveformOptions{
+			SampleRate:      transcode.Waveform.SampleRate,
+			SamplesPerPixel: transcode.Waveform.SamplesPerPixel,
+		}
+		opts.Waveform = waveform
+		// 采样率与分辨率写入缓存键，修改配置后旧的波形不再命中
+		extra = append(extra, "wf="+waveform.Tag())
+	}
+
+	key.Options = strings.Join(extra, ";")
+	return opts, key, nil
 }
 
 // ParseRequest 解析请求结构
 type ParseRequest struct {
 	BV      string `form:"bv" binding:"required" json:"bv"` // BV号
 	Quality int    `form:"quality" json:"quality"`          // 音质 (可选)
-	Token   string `form:"token" json:"token"`              // 访问令牌 (可选)
+	Token   string `form:"token" json:"token"`              // 访问令牌，启用auth时由鉴权中间件校验
+	ConvertParams
 }
 
 // ParseResponse 解析响应结构
@@ -59,28 +169,206 @@ func (h *ParseHandler) ParseAudio(c *gin.Context) {
 		return
 	}
 
-	// 1. 检查缓存
-	if cached := h.cache.Get(req.BV, req.Quality); cached != nil {
-		h.logRequest(c, req.BV, req.Quality, http.StatusOK, "", startTime)
-		utils.SuccessResponse(c, cached)
+	opts, key, err := req.options(h.transcode, h.profiles)
+	if err != nil {
+		h.logRequest(c, req.BV, req.Quality, http.StatusBadRequest, err.Error(), startTime)
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: "+err.Error())
 		return
 	}
 
-	// 2. 解析音频
-	audioInfo, err := h.parser.ParseAudio(req.BV, req.Quality)
+	audioInfo, err := h.resolveVideo(req.BV, req.Quality, opts, key)
 	if err != nil {
 		h.logRequest(c, req.BV, req.Quality, http.StatusInternalServerError, err.Error(), startTime)
 		utils.ErrorResponse(c, http.StatusInternalServerError, "解析失败: "+err.Error())
 		return
 	}
 
+	h.logRequest(c, req.BV, req.Quality, http.StatusOK, "", startTime)
+	utils.SuccessResponse(c, audioInfo)
+}
+
+// resolveVideo 获取视频音频，优先使用缓存，未命中时解析并缓存结果，key为options返回的缓存键
+func (h *ParseHandler) resolveVideo(bvid string, quality int, opts audio.ConvertOptions, key cache.Key) (*models.AudioInfo, error) {
+	key.Source, key.Quality, key.Account = bvid, quality, h.parser.AccountTier()
+
+	// 1. 检查缓存，元数据或原始链接过期时在后台刷新
+	revalidate := func() (*models.AudioInfo, error) {
+		return h.parser.RefreshAudio(bvid, quality)
+	}
+	if cached := h.cache.GetFresh(key, revalidate); cached != nil {
+		return cached, nil
+	}
+
+	// 2. 解析音频
+	audioInfo, err := h.parser.ParseAudio(bvid, quality, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	// 3. 缓存结果
-	if err := h.cache.Set(req.BV, req.Quality, audioInfo); err != nil {
+	if err := h.cache.Set(key, audioInfo); err != nil {
 		// 缓存失败不影响正常响应，只记录警告
 		// 可以考虑添加日志记录
 	}
 
-	h.logRequest(c, req.BV, req.Quality, http.StatusOK, "", startTime)
+	return audioInfo, nil
+}
+
+// Prewarm 以默认输出参数解析并缓存视频，与不带输出参数的 /parse 请求命中同一缓存，已缓存时返回true
+func (h *ParseHandler) Prewarm(bvid string, quality int) (bool, error) {
+	if !utils.IsValidBVID(bvid) {
+		return false, fmt.Errorf("invalid bvid: %s", bvid)
+	}
+
+	opts, key, err := ConvertParams{}.options(h.transcode, h.profiles)
+	if err != nil {
+		return false, err
+	}
+	key.Source, key.Quality, key.Account = bvid, quality, h.parser.AccountTier()
+	if h.cache.Has(key) {
+		return true, nil
+	}
+
+	_, err = h.resolveVideo(bvid, quality, opts, key)
+	return false, err
+}
+
+// SongRequest 音频区歌曲解析请求结构
+type SongRequest struct {
+	AU      string `form:"au" binding:"required" json:"au"` // au号或音频区链接
+	Quality *int   `form:"quality" json:"quality"`          // 音频区音质 0:128K 1:192K 2:320K 3:无损 (可选，默认320K)
+	Token   string `form:"token" json:"token"`              // 访问令牌，启用auth时由鉴权中间件校验
+	ConvertParams
+}
+
+// ParseSong 音频区歌曲解析接口
+func (h *ParseHandler) ParseSong(c *gin.Context) {
+	startTime := time.Now()
+
+	var req SongRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logRequest(c, req.AU, -1, http.StatusBadRequest, err.Error(), startTime)
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	quality := -1
+	if req.Quality != nil {
+		quality = *req.Quality
+	}
+
+	sid, err := bilibili.ParseSongID(req.AU)
+	if err != nil {
+		h.logRequest(c, req.AU, quality, http.StatusBadRequest, "无效的au号格式", startTime)
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的au号格式")
+		return
+	}
+	source := bilibili.SongKey(sid)
+
+	opts, key, err := req.options(h.transcode, h.profiles)
+	if err != nil {
+		h.logRequest(c, source, quality, http.StatusBadRequest, err.Error(), startTime)
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+	key.Source, key.Quality, key.Account = source, quality, h.parser.AccountTier()
+
+	// 1. 检查缓存，元数据或原始链接过期时在后台刷新
+	revalidate := func() (*models.AudioInfo, error) {
+		return h.parser.RefreshSong(sid, quality)
+	}
+	if cached := h.cache.GetFresh(key, revalidate); cached != nil {
+		h.logRequest(c, source, quality, http.StatusOK, "", startTime)
+		utils.SuccessResponse(c, cached)
+		return
+	}
+
+	// 2. 解析音频
+	audioInfo, err := h.parser.ParseSong(sid, quality, opts)
+	if err != nil {
+		h.logRequest(c, source, quality, http.StatusInternalServerError, err.Error(), startTime)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "解析失败: "+err.Error())
+		return
+	}
+
+	// 3. 缓存结果
+	if err := h.cache.Set(key, audioInfo); err != nil {
+		// 缓存失败不影响正常响应，只记录警告
+		fmt.Printf("Warning: failed to cache %s: %v\n", source, err)
+	}
+
+	h.logRequest(c, source, quality, http.StatusOK, "", startTime)
+	utils.SuccessResponse(c, audioInfo)
+}
+
+// EpisodeRequest 番剧/影视解析请求结构
+type EpisodeRequest struct {
+	ID      string `form:"id" binding:"required" json:"id"` // ep号、ss号或番剧链接
+	Quality int    `form:"quality" json:"quality"`          // 音质 (可选)
+	Token   string `form:"token" json:"token"`              // 访问令牌，启用auth时由鉴权中间件校验
+	ConvertParams
+}
+
+// ParseEpisode 番剧/影视单集音频解析接口
+func (h *ParseHandler) ParseEpisode(c *gin.Context) {
+	startTime := time.Now()
+
+	var req EpisodeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logRequest(c, req.ID, req.Quality, http.StatusBadRequest, err.Error(), startTime)
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	epID, seasonID, err := bilibili.ParsePGCID(req.ID)
+	if err != nil {
+		h.logRequest(c, req.ID, req.Quality, http.StatusBadRequest, "无效的ep/ss号格式", startTime)
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的ep/ss号格式")
+		return
+	}
+
+	source := bilibili.EpisodeKey(epID, seasonID)
+
+	opts, key, err := req.options(h.transcode, h.profiles)
+	if err != nil {
+		h.logRequest(c, source, req.Quality, http.StatusBadRequest, err.Error(), startTime)
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+	key.Source, key.Quality, key.Account = source, req.Quality, h.parser.AccountTier()
+
+	// 1. 检查缓存，元数据或原始链接过期时在后台刷新
+	revalidate := func() (*models.AudioInfo, error) {
+		return h.parser.RefreshEpisode(epID, seasonID, req.Quality)
+	}
+	if cached := h.cache.GetFresh(key, revalidate); cached != nil {
+		h.logRequest(c, source, req.Quality, http.StatusOK, "", startTime)
+		utils.SuccessResponse(c, cached)
+		return
+	}
+
+	// 2. 解析音频
+	audioInfo, err := h.parser.ParseEpisode(epID, seasonID, req.Quality, opts)
+	if err != nil {
+		statusCode, message := http.StatusInternalServerError, "解析失败: "+err.Error()
+		switch {
+		case errors.Is(err, bilibili.ErrRegionRestricted):
+			statusCode, message = http.StatusUnavailableForLegalReasons, "所在地区不可观看"
+		case errors.Is(err, bilibili.ErrPermissionDenied):
+			statusCode, message = http.StatusForbidden, "权限不足: 需要大会员或购买后观看"
+		}
+		h.logRequest(c, source, req.Quality, statusCode, err.Error(), startTime)
+		utils.ErrorResponse(c, statusCode, message)
+		return
+	}
+
+	// 3. 缓存结果
+	if err := h.cache.Set(key, audioInfo); err != nil {
+		// 缓存失败不影响正常响应，只记录警告
+		fmt.Printf("Warning: failed to cache %s: %v\n", source, err)
+	}
+
+	h.logRequest(c, source, req.Quality, http.StatusOK, "", startTime)
 	utils.SuccessResponse(c, audioInfo)
 }
 
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/bilibili"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PlaylistHandler struct {
+	parser *bilibili.AudioParser
+}
+
+func NewPlaylistHandler(parser *bilibili.AudioParser) *PlaylistHandler {
+	return &PlaylistHandler{
+		parser: parser,
+	}
+}
+
+// PlaylistRequest 播放列表请求结构，以下来源任选其一
+type PlaylistRequest struct {
+	BV       string `form:"bv" json:"bv"`               // 合集内任一视频的BV号
+	SeasonID int64  `form:"season_id" json:"season_id"` // 合集ID (需同时提供mid)
+	SeriesID int64  `form:"series_id" json:"series_id"` // 系列ID
+	MID      int64  `form:"mid" json:"mid"`             // UP主ID
+	Fav      string `form:"fav" json:"fav"`             // 收藏夹media_id或链接
+	Menu     string `form:"menu" json:"menu"`           // 音频区歌单am号或链接
+	Bangumi  string `form:"bangumi" json:"bangumi"`     // 番剧ss/ep号或链接
+}
+
+// GetPlaylist 获取播放列表接口
+func (h *PlaylistHandler) GetPlaylist(c *gin.Context) {
+	var req PlaylistRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	var (
+		playlist *models.Playlist
+		err      error
+	)
+
+	switch {
+	case req.Fav != "":
+		mediaID, parseErr := bilibili.ParseFavoriteID(req.Fav)
+		if parseErr != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "无效的收藏夹ID或链接")
+			return
+		}
+		playlist, err = h.parser.ResolveFavorite(mediaID)
+	case req.Bangumi != "":
+		epID, seasonID, parseErr := bilibili.ParsePGCID(req.Bangumi)
+		if parseErr != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "无效的ep/ss号格式")
+			return
+		}
+		playlist, err = h.parser.ResolveBangumi(epID, seasonID)
+	case req.Menu != "":
+		menuID, parseErr := bilibili.ParseMenuID(req.Menu)
+		if parseErr != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "无效的歌单ID或链接")
+			return
+		}
+		playlist, err = h.parser.ResolveMenu(menuID)
+	case req.SeasonID > 0:
+		if req.MID <= 0 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "获取合集需要提供mid参数")
+			return
+		}
+		playlist, err = h.parser.ResolveSeason(req.MID, req.SeasonID)
+	case req.SeriesID > 0:
+		playlist, err = h.parser.ResolveSeries(req.MID, req.SeriesID)
+	case req.BV != "":
+		if !utils.IsValidBVID(req.BV) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "无效的BV号格式")
+			return
+		}
+		playlist, err = h.parser.ResolveSeasonByBV(req.BV)
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: 需要提供bv、season_id、series_id、fav、menu或bangumi")
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, bilibili.ErrNotInCollection):
+			utils.ErrorResponse(c, http.StatusNotFound, "该视频不属于任何合集")
+			return
+		case errors.Is(err, bilibili.ErrRegionRestricted):
+			utils.ErrorResponse(c, http.StatusUnavailableForLegalReasons, "所在地区不可观看")
+			return
+		case errors.Is(err, bilibili.ErrPermissionDenied):
+			utils.ErrorResponse(c, http.StatusForbidden, "权限不足: 收藏夹未公开或内容需要大会员")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取播放列表失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, playlist)
+}
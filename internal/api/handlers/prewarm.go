@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/prewarm"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PrewarmHandler struct {
+	warmer *prewarm.Warmer
+	parse  *ParseHandler
+}
+
+func NewPrewarmHandler(warmer *prewarm.Warmer, parseHandler *ParseHandler) *PrewarmHandler {
+	return &PrewarmHandler{
+		warmer: warmer,
+		parse:  parseHandler,
+	}
+}
+
+// PrewarmRequest 预热请求结构
+type PrewarmRequest struct {
+	BVIDs   []string `json:"bvids"`   // BV号列表 (可选)
+	URLs    []string `json:"urls"`    // 需要查找视频的sitemap、RSS/Atom或HTML页面 (可选)
+	Quality int      `json:"quality"` // 音质 (可选)
+}
+
+// Enqueue 创建预热任务，在后台依次解析并缓存
+func (h *PrewarmHandler) Enqueue(c *gin.Context) {
+	var req PrewarmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	if len(req.BVIDs) == 0 && len(req.URLs) == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: bvids和urls不能同时为空")
+		return
+	}
+	for _, bvid := range req.BVIDs {
+		if !utils.IsValidBVID(bvid) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "无效的BV号格式: "+bvid)
+			return
+		}
+	}
+	for _, u := range req.URLs {
+		if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+			utils.ErrorResponse(c, http.StatusBadRequest, "无效的页面地址: "+u)
+			return
+		}
+	}
+
+	quality := req.Quality
+	task, err := h.warmer.Enqueue(prewarm.Request{BVIDs: req.BVIDs, URLs: req.URLs}, func(bvid string) (bool, error) {
+		return h.parse.Prewarm(bvid, quality)
+	})
+	if errors.Is(err, prewarm.ErrQueueFull) {
+		utils.ErrorResponse(c, http.StatusTooManyRequests, "预热队列已满，请稍后再试")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "创建预热任务失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, task)
+}
+
+// ListTasks 返回最近的预热任务及进度
+func (h *PrewarmHandler) ListTasks(c *gin.Context) {
+	utils.SuccessResponse(c, gin.H{
+		"tasks": h.warmer.Tasks(),
+	})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/cache"
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
 	"net/http"
 
@@ -9,12 +10,14 @@ import (
 )
 
 type StatusHandler struct {
-	db *gorm.DB
+	db           *gorm.DB
+	cacheManager *cache.Manager
 }
 
-func NewStatusHandler(db *gorm.DB) *StatusHandler {
+func NewStatusHandler(db *gorm.DB, cacheManager *cache.Manager) *StatusHandler {
 	return &StatusHandler{
-		db: db,
+		db:           db,
+		cacheManager: cacheManager,
 	}
 }
 
@@ -45,6 +48,9 @@ func (h *StatusHandler) GetStatus(c *gin.Context) {
 		stats["total_requests"] = requestCount
 	}
 
+	// 各层缓存命中率
+	stats["cache"] = h.cacheManager.Stats()
+
 	response := StatusResponse{
 		Alive: true,
 		LoginStatus: map[string]string{
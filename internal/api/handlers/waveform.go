@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WaveformRequest 波形请求结构
+type WaveformRequest struct {
+	Quality         int `form:"quality" json:"quality"`                     // 音质 (可选)
+	SamplesPerPixel int `form:"samples_per_pixel" json:"samples_per_pixel"` // 每像素采样数 (可选，指定时跳转到最接近的波形文件)
+	ConvertParams
+}
+
+// GetWaveform 获取视频音频的波形峰值，未缓存时先解析音频
+func (h *ParseHandler) GetWaveform(c *gin.Context) {
+	startTime := time.Now()
+
+	bvid := c.Param("bv")
+	if !utils.IsValidBVID(bvid) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的BV号格式")
+		return
+	}
+
+	var req WaveformRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	opts, key, err := req.options(h.transcode, h.profiles)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+	if opts.Waveform == nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "波形生成未启用")
+		return
+	}
+
+	audioInfo, err := h.resolveVideo(bvid, req.Quality, opts, key)
+	if err != nil {
+		h.logRequest(c, bvid, req.Quality, http.StatusInternalServerError, err.Error(), startTime)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "解析失败: "+err.Error())
+		return
+	}
+	h.logRequest(c, bvid, req.Quality, http.StatusOK, "", startTime)
+
+	if len(audioInfo.Waveforms) == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, "该音频没有可用的波形数据")
+		return
+	}
+
+	if req.SamplesPerPixel <= 0 {
+		utils.SuccessResponse(c, audioInfo.Waveforms)
+		return
+	}
+
+	c.Redirect(http.StatusFound, selectWaveform(audioInfo.Waveforms, req.SamplesPerPixel).URL)
+}
+
+// selectWaveform 选择每像素采样数最接近的波形文件
+func selectWaveform(waveforms []models.Waveform, samplesPerPixel int) *models.Waveform {
+	best := &waveforms[0]
+	for i := range waveforms {
+		if distance(waveforms[i].SamplesPerPixel, samplesPerPixel) < distance(best.SamplesPerPixel, samplesPerPixel) {
+			best = &waveforms[i]
+		}
+	}
+	return best
+}
+
+func distance(a, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
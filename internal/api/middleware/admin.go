@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth 管理接口鉴权中间件，令牌通过 Authorization: Bearer <token> 或 X-Admin-Token 请求头传递
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := requestToken(c, "X-Admin-Token")
+		if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "未授权")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
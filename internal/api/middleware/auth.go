@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/auth"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdentityKey 通过鉴权的调用方 (*auth.Identity) 在gin.Context中的键
+const IdentityKey = "auth_identity"
+
+// Auth 访问令牌鉴权中间件，令牌通过 token 查询参数、X-API-Key 请求头或 Authorization: Bearer <token> 传递
+// 查询参数便于 <audio> 等无法设置请求头的场景，请求日志中会隐去其值
+func Auth(authenticator *auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := requestToken(c, "X-API-Key")
+		if token == "" {
+			token = c.Query("token")
+		}
+		if token == "" {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "缺少访问令牌")
+			c.Abort()
+			return
+		}
+
+		identity, err := authenticator.Authenticate(token)
+		if err != nil {
+			// 错误可能包含数据库细节，只记录在日志中
+			LogError(err, map[string]interface{}{
+				"stage": "authenticate",
+				"path":  c.Request.URL.Path,
+			})
+			utils.ErrorResponse(c, http.StatusInternalServerError, "鉴权失败")
+			c.Abort()
+			return
+		}
+		if identity == nil {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "无效的访问令牌")
+			c.Abort()
+			return
+		}
+
+		c.Set(IdentityKey, identity)
+		c.Next()
+	}
+}
+
+// requestToken 读取 Authorization: Bearer <token>，没有时读取header指定的请求头
+func requestToken(c *gin.Context, header string) string {
+	if value := c.GetHeader("Authorization"); strings.HasPrefix(value, "Bearer ") {
+		return strings.TrimPrefix(value, "Bearer ")
+	}
+	return c.GetHeader(header)
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,7 +17,7 @@ func Logger() gin.HandlerFunc {
 			"type":       "http_request",
 			"client_ip":  param.ClientIP,
 			"method":     param.Method,
-			"path":       param.Path,
+			"path":       redactToken(param.Path),
 			"status":     param.StatusCode,
 			"latency":    param.Latency.String(),
 			"user_agent": param.Request.UserAgent(),
@@ -26,6 +28,24 @@ func Logger() gin.HandlerFunc {
 	})
 }
 
+// redactToken 隐去请求路径中token查询参数的值，避免访问令牌写入日志
+func redactToken(path string) string {
+	i := strings.IndexByte(path, '?')
+	if i < 0 {
+		return path
+	}
+	query, err := url.ParseQuery(path[i+1:])
+	if err != nil {
+		// 无法解析时整体去掉查询参数
+		return path[:i]
+	}
+	if !query.Has("token") {
+		return path
+	}
+	query.Set("token", "REDACTED")
+	return path[:i+1] + query.Encode()
+}
+
 // LogError 记录错误日志
 func LogError(err error, context map[string]interface{}) {
 	fields := logrus.Fields{
@@ -3,16 +3,22 @@ package routes
 import (
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/api/handlers"
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/api/middleware"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/auth"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/bilibili"
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/cache"
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/config"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/live"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/prewarm"
+	"mime"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-// SetupRouter 设置路由
-func SetupRouter(cfg *config.Config, db *gorm.DB) *gin.Engine {
+// SetupRouter 设置路由，cacheManager由调用方创建并负责启动清理任务
+func SetupRouter(cfg *config.Config, db *gorm.DB, cacheManager *cache.Manager) (*gin.Engine, error) {
 	// 设置Gin模式
 	if !cfg.Server.Debug {
 		gin.SetMode(gin.ReleaseMode)
@@ -39,32 +45,91 @@ func SetupRouter(cfg *config.Config, db *gorm.DB) *gin.Engine {
 		))
 	}
 
-	// 初始化缓存管理器
-	cacheManager := cache.NewManager(cfg.Cache.Dir, cfg.Cache.TTL, db)
-
-	// 启动缓存清理任务
-	cacheManager.StartCleanupWorker(cfg.Cache.CleanupInterval)
-
-	// 初始化处理器
-	parseHandler := handlers.NewParseHandler(
+	// 初始化处理器，共用同一个解析器 (及其下载器的输出文件锁)
+	parser := bilibili.NewAudioParser(
 		cfg.Bilibili.UserAgent,
 		cfg.Bilibili.Referer,
 		cfg.Cache.Dir,
-		cacheManager,
-		db,
+		cacheManager.Storage(),
 	)
-	statusHandler := handlers.NewStatusHandler(db)
+	parseHandler, err := handlers.NewParseHandler(parser, cfg.Transcode, cacheManager, db)
+	if err != nil {
+		return nil, err
+	}
+	playlistHandler := handlers.NewPlaylistHandler(parser)
+	statusHandler := handlers.NewStatusHandler(db, cacheManager)
 
-	// 静态文件服务器 - 提供MP3文件访问
-	router.Static("/static", cfg.Cache.Dir)
+	// 静态文件服务器 - 由存储后端提供音频文件访问 (本地缓存目录或转发对象存储)，无需访问令牌
+	// 注册HLS相关类型，系统mime表可能缺失或将.ts识别为TypeScript
+	mime.AddExtensionType(".m3u8", "application/vnd.apple.mpegurl")
+	mime.AddExtensionType(".m4s", "video/iso.segment")
+	mime.AddExtensionType(".ts", "video/mp2t")
+	// 临时文件目录 (.staging) 不对外提供
+	staticHandler := gin.WrapH(http.StripPrefix("/static", cacheManager.Storage().Handler()))
+	router.GET("/static/*filepath", staticHandler)
+	router.HEAD("/static/*filepath", staticHandler)
+
+	// 访问令牌鉴权，未启用时解析接口同样公开
+	authenticator := auth.NewAuthenticator(cfg.Auth.Tokens, db)
 
 	// API路由组
 	v1 := router.Group("/api/v1")
 	{
-		v1.GET("/parse", parseHandler.ParseAudio)    // 音频解析
 		v1.GET("/status", statusHandler.GetStatus)   // 服务状态
 		v1.GET("/health", statusHandler.HealthCheck) // 健康检查
+
+		// 需要访问令牌的接口
+		protected := v1.Group("")
+		if cfg.Auth.Enabled {
+			protected.Use(middleware.Auth(authenticator))
+		}
+		protected.GET("/parse", parseHandler.ParseAudio)         // 音频解析
+		protected.GET("/song", parseHandler.ParseSong)           // 音频区歌曲解析
+		protected.GET("/bangumi", parseHandler.ParseEpisode)     // 番剧/影视音频解析
+		protected.GET("/playlist", playlistHandler.GetPlaylist)  // 播放列表 (合集/系列/收藏夹/歌单/番剧)
+		protected.GET("/lyrics/:bv", parseHandler.GetLyrics)     // CC字幕歌词
+		protected.GET("/waveform/:bv", parseHandler.GetWaveform) // 波形峰值
+
+		if cfg.Live.Enabled {
+			liveHandler := handlers.NewLiveHandler(live.NewManager(
+				cfg.Live,
+				cfg.Bilibili.UserAgent,
+				"https://live.bilibili.com",
+			))
+			protected.GET("/live/:roomid/audio", liveHandler.StreamAudio) // 直播音频转发
+		}
+
+		// 管理接口，未配置admin.token时不开放
+		if cfg.Admin.Token != "" {
+			warmer := prewarm.NewWarmer(
+				prewarm.NewDiscoverer(cfg.Bilibili.UserAgent, cfg.Prewarm.MaxPages),
+				cfg.Prewarm.Delay,
+				cfg.Prewarm.QueueSize,
+			)
+			warmer.Start()
+			prewarmHandler := handlers.NewPrewarmHandler(warmer, parseHandler)
+			cacheAdminHandler := handlers.NewCacheAdminHandler(cacheManager)
+			apiKeyHandler := handlers.NewAPIKeyHandler(authenticator)
+
+			admin := v1.Group("/admin", middleware.AdminAuth(cfg.Admin.Token))
+			admin.POST("/prewarm", prewarmHandler.Enqueue)  // 创建预热任务
+			admin.GET("/prewarm", prewarmHandler.ListTasks) // 预热任务进度
+			admin.GET("/pins", cacheAdminHandler.ListPins)  // 固定的缓存条目
+			admin.PUT("/pins", cacheAdminHandler.Pin)       // 固定缓存条目
+			admin.DELETE("/pins", cacheAdminHandler.Unpin)  // 取消固定
+
+			admin.GET("/cache", cacheAdminHandler.ListEntries)          // 缓存条目列表
+			admin.GET("/cache/:key", cacheAdminHandler.GetEntry)        // 缓存条目详情
+			admin.DELETE("/cache", cacheAdminHandler.Purge)             // 按条件清除缓存
+			admin.DELETE("/cache/:key", cacheAdminHandler.Purge)        // 清除单个缓存条目
+			admin.POST("/cache/cleanup", cacheAdminHandler.Cleanup)     // 立即清理过期条目
+			admin.POST("/cache/reconcile", cacheAdminHandler.Reconcile) // 立即对账
+
+			admin.GET("/apikeys", apiKeyHandler.ListKeys)         // 访问密钥列表
+			admin.POST("/apikeys", apiKeyHandler.CreateKey)       // 创建访问密钥
+			admin.DELETE("/apikeys/:id", apiKeyHandler.RevokeKey) // 吊销访问密钥
+		}
 	}
 
-	return router
+	return router, nil
 }
@@ -0,0 +1,51 @@
+package audio
+
+import (
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"os"
+	"strings"
+)
+
+// ffmetadataEscaper 转义FFMETADATA中的特殊字符
+var ffmetadataEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"=", `\=`,
+	";", `\;`,
+	"#", `\#`,
+	"\n", `\`+"\n",
+)
+
+// writeChapterMetadata 生成ffmpeg的FFMETADATA章节文件
+// MP3输出时ffmpeg写入ID3 CHAP/CTOC帧，M4A输出时写入章节atom
+func writeChapterMetadata(path string, chapters []models.Chapter, duration int) error {
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+
+	for i, chapter := range chapters {
+		end := chapter.End
+		if end <= chapter.Start {
+			// 缺少结束时间时使用下一章节的开始或音频总时长
+			if i+1 < len(chapters) {
+				end = chapters[i+1].Start
+			} else {
+				end = duration
+			}
+		}
+		if end <= chapter.Start {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			chapter.Start*1000,
+			end*1000,
+			ffmetadataEscaper.Replace(chapter.Title),
+		)
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write chapter metadata: %w", err)
+	}
+
+	return nil
+}
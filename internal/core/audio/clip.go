@@ -0,0 +1,148 @@
+package audio
+
+import (
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"strconv"
+	"strings"
+)
+
+// ClipRange 音频片段截取范围，时间单位为秒
+type ClipRange struct {
+	Start   float64 // 开始时间
+	End     float64 // 结束时间，0表示截取到音频结尾
+	FadeIn  float64 // 淡入时长
+	FadeOut float64 // 淡出时长
+}
+
+// Validate 校验截取范围
+func (c *ClipRange) Validate() error {
+	if c.Start < 0 || c.End < 0 || c.FadeIn < 0 || c.FadeOut < 0 {
+		return fmt.Errorf("clip times must not be negative")
+	}
+	if c.End > 0 && c.End <= c.Start {
+		return fmt.Errorf("clip end must be greater than start")
+	}
+	if c.End > 0 && c.FadeIn+c.FadeOut > c.End-c.Start {
+		return fmt.Errorf("fade durations exceed clip length")
+	}
+	return nil
+}
+
+// Tag 截取参数的标识，用于区分缓存和文件名，如 "30-60" 或 "30-60-f2-3"
+func (c *ClipRange) Tag() string {
+	tag := formatSeconds(c.Start) + "-" + formatSeconds(c.End)
+	if c.FadeIn > 0 || c.FadeOut > 0 {
+		tag += "-f" + formatSeconds(c.FadeIn) + "-" + formatSeconds(c.FadeOut)
+	}
+	return tag
+}
+
+// resolveEnd 返回实际结束时间，不超过音频总时长
+func (c *ClipRange) resolveEnd(duration int) float64 {
+	if c.End <= 0 || (duration > 0 && c.End > float64(duration)) {
+		return float64(duration)
+	}
+	return c.End
+}
+
+// Duration 截取后的时长(秒)
+func (c *ClipRange) Duration(duration int) int {
+	d := c.resolveEnd(duration) - c.Start
+	if d < 0 {
+		return 0
+	}
+	return int(d + 0.5)
+}
+
+// filter 转码时的截取滤镜，按采样精确截取并添加淡入淡出
+func (c *ClipRange) filter(duration int) string {
+	filters := []string{"atrim=start=" + formatSeconds(c.Start)}
+	end := c.resolveEnd(duration)
+	if end > 0 {
+		filters[0] += ":end=" + formatSeconds(end)
+	}
+	filters = append(filters, "asetpts=PTS-STARTPTS")
+
+	if c.FadeIn > 0 {
+		filters = append(filters, "afade=t=in:st=0:d="+formatSeconds(c.FadeIn))
+	}
+	if c.FadeOut > 0 && end > 0 {
+		st := end - c.Start - c.FadeOut
+		if st < 0 {
+			st = 0
+		}
+		filters = append(filters, "afade=t=out:st="+formatSeconds(st)+":d="+formatSeconds(c.FadeOut))
+	}
+
+	return strings.Join(filters, ",")
+}
+
+// inputArgs 转封装时的输入侧截取参数，按音频包对齐 (AAC约21ms一包)
+func (c *ClipRange) inputArgs(duration int) []string {
+	args := []string{"-ss", formatSeconds(c.Start)}
+	if end := c.resolveEnd(duration); end > 0 {
+		args = append(args, "-t", formatSeconds(end-c.Start))
+	}
+	return args
+}
+
+// ClipChapters 将章节裁剪到截取范围内并平移到新的时间轴
+func (c *ClipRange) ClipChapters(chapters []models.Chapter, duration int) []models.Chapter {
+	start := int(c.Start)
+	end := int(c.resolveEnd(duration))
+
+	var clipped []models.Chapter
+	for _, chapter := range chapters {
+		chEnd := chapter.End
+		if chEnd <= chapter.Start {
+			chEnd = end
+		}
+		if chEnd <= start || (end > 0 && chapter.Start >= end) {
+			continue
+		}
+
+		from, to := chapter.Start, chEnd
+		if from < start {
+			from = start
+		}
+		if end > 0 && to > end {
+			to = end
+		}
+		clipped = append(clipped, models.Chapter{
+			Title: chapter.Title,
+			Start: from - start,
+			End:   to - start,
+		})
+	}
+	return clipped
+}
+
+// ClipLyrics 将歌词行裁剪到截取范围内并平移到新的时间轴
+func (c *ClipRange) ClipLyrics(lines []LyricLine) []LyricLine {
+	var clipped []LyricLine
+	for _, line := range lines {
+		if line.To <= c.Start || (c.End > 0 && line.From >= c.End) {
+			continue
+		}
+
+		from, to := line.From, line.To
+		if from < c.Start {
+			from = c.Start
+		}
+		if c.End > 0 && to > c.End {
+			to = c.End
+		}
+		clipped = append(clipped, LyricLine{
+			From:    from - c.Start,
+			To:      to - c.Start,
+			Content: line.Content,
+		})
+	}
+	return clipped
+}
+
+// formatSeconds 格式化秒数，去掉多余的小数位
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', -1, 64)
+}
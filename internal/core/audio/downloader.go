@@ -1,7 +1,12 @@
 package audio
 
 import (
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/storage"
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,19 +14,30 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
 // Downloader 音频下载器
 type Downloader struct {
-	cacheDir  string
+	cacheDir  string          // 本地缓存目录，下载与转码在其中的临时目录进行
+	store     storage.Storage // 转换输出的存储后端
 	userAgent string
 	referer   string
 	client    *http.Client
+
+	mu    sync.Mutex
+	locks map[string]*outputLock // 正在生成的输出文件，避免同一输出被并发转换
+}
+
+// outputLock 单个输出文件的转换锁
+type outputLock struct {
+	mu   sync.Mutex
+	refs int
 }
 
 // NewDownloader 创建音频下载器
-func NewDownloader(cacheDir, userAgent, referer string) *Downloader {
+func NewDownloader(cacheDir string, store storage.Storage, userAgent, referer string) *Downloader {
 	// 确保缓存目录存在
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
 		fmt.Printf("Warning: failed to create cache directory %s: %v\n", cacheDir, err)
@@ -29,68 +45,297 @@ func NewDownloader(cacheDir, userAgent, referer string) *Downloader {
 
 	return &Downloader{
 		cacheDir:  cacheDir,
+		store:     store,
 		userAgent: userAgent,
 		referer:   referer,
 		client: &http.Client{
 			Timeout: 5 * time.Minute, // 增加超时时间以支持大文件下载
 		},
+		locks: make(map[string]*outputLock),
 	}
 }
 
-// DownloadAndConvert 下载音频并转换为MP3
-func (d *Downloader) DownloadAndConvert(bvid string, quality int, dashURL string, bitrate int, duration int) (*models.AudioInfo, error) {
+// ConvertOptions 转换选项
+type ConvertOptions struct {
+	Profile    *Profile         // 转码配置，nil表示使用DefaultProfile
+	Chapters   []models.Chapter // 写入输出文件的章节
+	Clip       *ClipRange       // 截取片段，nil表示完整音频
+	Normalize  *LoudnessTarget  // 两遍响度标准化目标，nil表示不做标准化
+	ReplayGain bool             // 测量响度并写入ReplayGain标签
+	Waveform   *WaveformOptions // 转码后生成波形峰值，nil表示不生成
+	HLS        *HLSOptions      // 同时封装为HLS，nil表示只输出单个文件
+}
+
+// DownloadAndConvert 下载音频并按转码配置转换 (默认为MP3)
+// source为音频源标识 (视频为 c<cid>，音频区为 au<sid>)，stream为音频流编号，
+// 输出文件名由二者与转换选项确定，相同音频经不同入口请求时共用同一文件
+// codec为源音频编码 (DASH codecs)，copy配置按其选择输出封装格式
+func (d *Downloader) DownloadAndConvert(source string, stream int, codec string, dashURL string, bitrate int, duration int, opts ConvertOptions) (*models.AudioInfo, error) {
+	profile := opts.Profile
+	if profile == nil {
+		profile = &DefaultProfile
+	}
+	if profile.IsCopy() && opts.Normalize != nil {
+		return nil, fmt.Errorf("profile %s does not re-encode and cannot normalize loudness", profile.Name)
+	}
+
+	// 截取片段时章节与时长都以片段为准
+	// audioFilter 用于响度测量、转码与HLS，copy配置转封装时改用inputArgs按包截取
+	var audioFilter string
+	var inputArgs []string
+	if opts.Clip != nil {
+		if duration > 0 && opts.Clip.Start >= float64(duration) {
+			return nil, fmt.Errorf("clip start %.1fs exceeds audio duration %ds", opts.Clip.Start, duration)
+		}
+		if profile.IsCopy() {
+			if opts.Clip.FadeIn > 0 || opts.Clip.FadeOut > 0 {
+				return nil, fmt.Errorf("profile %s does not re-encode and cannot apply fades", profile.Name)
+			}
+			inputArgs = opts.Clip.inputArgs(duration)
+		}
+		audioFilter = opts.Clip.filter(duration)
+		opts.Chapters = opts.Clip.ClipChapters(opts.Chapters, duration)
+		duration = opts.Clip.Duration(duration)
+	}
+	if profile.Filters != "" {
+		if audioFilter != "" {
+			audioFilter += ","
+		}
+		audioFilter += profile.Filters
+	}
+
 	// 生成文件名
-	fileName := fmt.Sprintf("%s_%d_%d.mp3", bvid, quality, time.Now().Unix())
-	mp3Path := filepath.Join(d.cacheDir, fileName)
-
-	// 检查文件是否已存在
-	if _, err := os.Stat(mp3Path); err == nil {
-		// 文件已存在，返回现有文件信息
-		stat, _ := os.Stat(mp3Path)
-		return &models.AudioInfo{
-			URL:         "/static/" + fileName,
-			OriginalURL: dashURL,
-			Format:      "mp3",
-			Bitrate:     bitrate,
-			Duration:    duration,
-			Quality:     quality,
-			Size:        stat.Size(),
-			FileName:    fileName,
-		}, nil
+	extension, err := profile.Extension(codec)
+	if err != nil {
+		return nil, err
+	}
+	baseName := outputName(source, stream, opts)
+	fileName := baseName + "." + extension
+	outputBitrate := profile.outputBitrate(bitrate)
+
+	unlock := d.lockOutput(fileName)
+	defer unlock()
+
+	// 检查文件是否已存在，存在时复用上次转换的结果
+	if _, err := d.store.Stat(fileName); err == nil {
+		if info, err := d.readManifest(fileName); err == nil {
+			info.OriginalURL = dashURL
+			storage.ResolveURLs(d.store, info)
+			return info, nil
+		}
+		// 清单缺失或损坏，重新转换
+		d.store.Delete(fileName)
 	}
 
+	// 转换过程中的文件都写入临时目录，完成后再移入存储，/static不会提供不完整的文件
+	stagingDir := utils.StagingDir(d.cacheDir)
+	if err := os.MkdirAll(stagingDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	workDir, err := os.MkdirTemp(stagingDir, baseName+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
 	// 1. 下载m4s文件
-	m4sPath := strings.Replace(mp3Path, ".mp3", ".m4s", 1)
+	m4sPath := filepath.Join(workDir, baseName+".m4s")
 	if err := d.downloadFile(dashURL, m4sPath); err != nil {
 		return nil, fmt.Errorf("failed to download m4s file: %w", err)
 	}
 
-	// 2. 转换为MP3
-	if err := d.convertToMP3(m4sPath, mp3Path, bitrate); err != nil {
-		// 清理临时文件
-		os.Remove(m4sPath)
-		return nil, fmt.Errorf("failed to convert to mp3: %w", err)
+	// 2. 测量响度 (标准化或ReplayGain)，失败时按原响度输出
+	var loudness *models.Loudness
+	var tags map[string]string
+	if opts.Normalize != nil || opts.ReplayGain {
+		target := LoudnessTarget{I: -16, TP: -1.5, LRA: 11}
+		if opts.Normalize != nil {
+			target = *opts.Normalize
+		}
+
+		stats, err := measureLoudness(m4sPath, audioFilter, target)
+		if err == nil {
+			loudness, err = loudnessInfo(stats, opts.Normalize)
+		}
+		if err != nil {
+			fmt.Printf("Warning: loudness measurement failed for %s, skipping: %v\n", fileName, err)
+		} else {
+			if opts.Normalize != nil {
+				if audioFilter != "" {
+					audioFilter += ","
+				}
+				audioFilter += target.normalizeFilter(stats)
+			}
+			tags = replayGainTags(loudness)
+		}
+	}
+
+	// 3. 生成章节元数据
+	var metadataPath string
+	if len(opts.Chapters) > 0 {
+		metadataPath = filepath.Join(workDir, baseName+".ffmeta")
+		if err := writeChapterMetadata(metadataPath, opts.Chapters, duration); err != nil {
+			return nil, err
+		}
 	}
 
-	// 3. 清理临时m4s文件
-	os.Remove(m4sPath)
+	// 4. 按转码配置转换，copy配置直接转封装不应用滤镜
+	encodeFilter := audioFilter
+	if profile.IsCopy() {
+		encodeFilter = ""
+	}
+	stagedOutput := filepath.Join(workDir, fileName)
+	if err := d.transcode(m4sPath, stagedOutput, metadataPath, inputArgs, profile, bitrate, encodeFilter, tags); err != nil {
+		return nil, fmt.Errorf("failed to convert to %s: %w", extension, err)
+	}
+
+	// 5. 封装HLS，与输出文件使用相同的滤镜
+	var hls *models.HLS
+	if opts.HLS != nil {
+		hls, err = d.packageHLS(m4sPath, workDir, baseName+"_hls", audioFilter, bitrate, *opts.HLS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to package hls: %w", err)
+		}
+	}
 
-	// 4. 获取转换后的文件信息
-	stat, err := os.Stat(mp3Path)
+	// 6. 获取转换后的文件信息
+	stat, err := os.Stat(stagedOutput)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get mp3 file info: %w", err)
+		return nil, fmt.Errorf("failed to get output file info: %w", err)
 	}
 
-	return &models.AudioInfo{
-		URL:         "/static/" + fileName,
+	// 7. 生成波形峰值，失败时不影响音频输出
+	var waveforms []models.Waveform
+	if opts.Waveform != nil {
+		waveforms, err = d.generateWaveforms(stagedOutput, workDir, baseName, *opts.Waveform)
+		if err != nil {
+			fmt.Printf("Warning: failed to generate waveform for %s: %v\n", fileName, err)
+		}
+	}
+
+	// 8. 移入存储: 先配套文件，再音频文件
+	commit := func(name string) error {
+		return d.store.Put(name, filepath.Join(workDir, name))
+	}
+	if hls != nil {
+		if err := commit(hls.Dir); err != nil {
+			return nil, err
+		}
+	}
+	for _, waveform := range waveforms {
+		if err := commit(waveform.FileName); err != nil {
+			return nil, err
+		}
+	}
+	if err := commit(fileName); err != nil {
+		return nil, err
+	}
+
+	audioInfo := &models.AudioInfo{
 		OriginalURL: dashURL,
-		Format:      "mp3",
-		Bitrate:     bitrate,
+		Format:      extension,
+		Bitrate:     outputBitrate,
 		Duration:    duration,
-		Quality:     quality,
+		Quality:     stream,
 		Size:        stat.Size(),
 		FileName:    fileName,
-	}, nil
+		Chapters:    opts.Chapters,
+		Loudness:    loudness,
+		Waveforms:   waveforms,
+		HLS:         hls,
+	}
+	storage.ResolveURLs(d.store, audioInfo)
+
+	// 9. 最后写入转换结果清单，清单存在即表示输出完整，可供复用
+	if err := d.writeManifest(audioInfo); err != nil {
+		fmt.Printf("Warning: failed to write manifest for %s: %v\n", fileName, err)
+	}
+
+	return audioInfo, nil
+}
+
+// outputName 根据音频源、音频流与转换选项生成确定的文件名 (不含扩展名)
+// 完整音频为 <source>_<stream>，有转换选项时追加选项摘要
+func outputName(source string, stream int, opts ConvertOptions) string {
+	var parts []string
+	if opts.Profile != nil {
+		parts = append(parts, "profile="+opts.Profile.Tag())
+	}
+	if opts.Clip != nil {
+		parts = append(parts, "clip="+opts.Clip.Tag())
+	}
+	if opts.Normalize != nil {
+		parts = append(parts, fmt.Sprintf("norm=%g/%g/%g", opts.Normalize.I, opts.Normalize.TP, opts.Normalize.LRA))
+	}
+	if opts.ReplayGain {
+		parts = append(parts, "rg")
+	}
+	if opts.HLS != nil {
+		parts = append(parts, "hls="+opts.HLS.Tag())
+	}
+	if opts.Waveform != nil {
+		parts = append(parts, "wf="+opts.Waveform.Tag())
+	}
+
+	name := fmt.Sprintf("%s_%d", source, stream)
+	if len(parts) > 0 {
+		sum := sha1.Sum([]byte(strings.Join(parts, ";")))
+		name += "_" + hex.EncodeToString(sum[:6])
+	}
+	return name
+}
+
+// lockOutput 锁定输出文件，同一输出同时只有一个转换，返回解锁函数
+func (d *Downloader) lockOutput(fileName string) func() {
+	d.mu.Lock()
+	lock, ok := d.locks[fileName]
+	if !ok {
+		lock = &outputLock{}
+		d.locks[fileName] = lock
+	}
+	lock.refs++
+	d.mu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+		d.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(d.locks, fileName)
+		}
+		d.mu.Unlock()
+	}
+}
+
+// writeManifest 保存转换结果清单
+func (d *Downloader) writeManifest(info *models.AudioInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	return d.putData(info.ManifestFile(), data)
+}
+
+// readManifest 读取已存在输出的转换结果清单
+func (d *Downloader) readManifest(fileName string) (*models.AudioInfo, error) {
+	info := &models.AudioInfo{FileName: fileName}
+	reader, err := d.store.Open(info.ManifestFile())
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if info.FileName != fileName {
+		return nil, fmt.Errorf("manifest does not match %s", fileName)
+	}
+	return info, nil
 }
 
 // downloadFile 下载文件
@@ -134,25 +379,44 @@ func (d *Downloader) downloadFile(url, filePath string) error {
 	return nil
 }
 
-// convertToMP3 使用ffmpeg转换为MP3
-func (d *Downloader) convertToMP3(inputPath, outputPath string, bitrate int) error {
+// transcode 使用ffmpeg按转码配置转换，metadataPath非空时写入其中的章节，inputArgs为输入侧参数 (转封装截取)，
+// audioFilter非空时应用音频滤镜，tags为额外写入的元数据标签
+func (d *Downloader) transcode(inputPath, outputPath, metadataPath string, inputArgs []string, profile *Profile, bitrate int, audioFilter string, tags map[string]string) error {
 	// 检查ffmpeg是否可用
 	if _, err := exec.LookPath("ffmpeg"); err != nil {
 		return fmt.Errorf("ffmpeg not found: %w", err)
 	}
 
 	// 构建ffmpeg命令
+	// -ss/-t: 输入侧截取 (转封装时按包截取)
 	// -i: 输入文件
-	// -acodec libmp3lame: 使用MP3编码器
-	// -ab: 音频比特率
+	// -map_chapters: 从元数据文件读取章节 (MP3写入ID3 CHAP/CTOC帧)
+	// -af: 音频滤镜 (片段截取、淡入淡出、配置滤镜、响度标准化)
+	// -metadata: 元数据标签 (ReplayGain)
+	// -acodec/-ab/-q:a/-ar/-ac: 转码配置的编码参数
 	// -y: 覆盖输出文件
-	cmd := exec.Command("ffmpeg",
-		"-i", inputPath,
-		"-acodec", "libmp3lame",
-		"-ab", fmt.Sprintf("%dk", bitrate),
-		"-y",
-		outputPath,
-	)
+	args := append([]string{}, inputArgs...)
+	args = append(args, "-i", inputPath)
+	if metadataPath != "" {
+		args = append(args,
+			"-i", metadataPath,
+			"-map", "0:a",
+			"-map_chapters", "1",
+		)
+		if profile.Codec == CodecMP3 {
+			args = append(args, "-id3v2_version", "3")
+		}
+	}
+	if audioFilter != "" {
+		args = append(args, "-af", audioFilter)
+	}
+	for key, value := range tags {
+		args = append(args, "-metadata", key+"="+value)
+	}
+	args = append(args, profile.encoderArgs(bitrate)...)
+	args = append(args, "-vn", "-y", outputPath)
+
+	cmd := exec.Command("ffmpeg", args...)
 
 	// 执行转换
 	output, err := cmd.CombinedOutput()
@@ -163,6 +427,42 @@ func (d *Downloader) convertToMP3(inputPath, outputPath string, bitrate int) err
 	return nil
 }
 
+// SaveSidecar 保存与音频配套的文件 (歌词等)，返回访问链接
+func (d *Downloader) SaveSidecar(fileName string, data []byte) (string, error) {
+	if err := d.putData(fileName, data); err != nil {
+		return "", fmt.Errorf("failed to write sidecar file: %w", err)
+	}
+	return d.store.URL(fileName), nil
+}
+
+// putData 将内容写入临时文件后移入存储
+func (d *Downloader) putData(name string, data []byte) error {
+	stagingDir := utils.StagingDir(d.cacheDir)
+	if err := os.MkdirAll(stagingDir, 0755); err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(stagingDir, name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = d.store.Put(name, tmp.Name())
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
 // GetFileSize 获取文件大小
 func (d *Downloader) GetFileSize(filePath string) (int64, error) {
 	stat, err := os.Stat(filePath)
@@ -174,8 +474,7 @@ func (d *Downloader) GetFileSize(filePath string) (int64, error) {
 
 // FileExists 检查文件是否存在
 func (d *Downloader) FileExists(fileName string) bool {
-	filePath := filepath.Join(d.cacheDir, fileName)
-	_, err := os.Stat(filePath)
+	_, err := d.store.Stat(fileName)
 	return err == nil
 }
 
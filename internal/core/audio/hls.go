@@ -0,0 +1,114 @@
+package audio
+
+import (
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// HLS分片封装格式
+const (
+	HLSSegmentFMP4 = "fmp4"
+	HLSSegmentTS   = "ts"
+)
+
+// HLSOptions HLS封装选项
+type HLSOptions struct {
+	SegmentType     string // fmp4 / ts
+	SegmentDuration int    // 分片时长(秒)
+	Bitrates        []int  // 各档AAC码率(kbps)，为空时使用源码率单档输出
+}
+
+// Tag HLS参数的标识，用于区分缓存，如 "fmp4-6-64-128"
+func (o *HLSOptions) Tag() string {
+	parts := []string{o.segmentType(), strconv.Itoa(o.segmentDuration())}
+	for _, bitrate := range o.Bitrates {
+		parts = append(parts, strconv.Itoa(bitrate))
+	}
+	return strings.Join(parts, "-")
+}
+
+func (o *HLSOptions) segmentType() string {
+	if o.SegmentType == HLSSegmentTS {
+		return HLSSegmentTS
+	}
+	return HLSSegmentFMP4
+}
+
+func (o *HLSOptions) segmentDuration() int {
+	if o.SegmentDuration <= 0 {
+		return 6
+	}
+	return o.SegmentDuration
+}
+
+// packageHLS 将源音频编码为AAC并封装为HLS，输出到 workDir 下的 dirName 子目录 (由调用方移动到缓存目录)
+// 每档码率一个播放列表 (stream_0.m3u8, stream_1.m3u8 ...)，由 master.m3u8 引用
+func (d *Downloader) packageHLS(inputPath, workDir, dirName, audioFilter string, sourceBitrate int, opts HLSOptions) (*models.HLS, error) {
+	bitrates := opts.Bitrates
+	if len(bitrates) == 0 {
+		bitrates = []int{sourceBitrate}
+	}
+
+	outputDir := filepath.Join(workDir, dirName)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create hls directory: %w", err)
+	}
+
+	segmentExt := "m4s"
+	if opts.segmentType() == HLSSegmentTS {
+		segmentExt = "ts"
+	}
+
+	// 构建ffmpeg命令
+	// -map 0:a 重复多次: 同一输入编码为多档码率
+	// -var_stream_map: 每档码率输出独立的播放列表，%v 为档位序号
+	// -hls_playlist_type vod: 输出完整的点播列表，便于CDN缓存
+	args := []string{"-hide_banner", "-loglevel", "error", "-i", inputPath}
+	if audioFilter != "" {
+		args = append(args, "-af", audioFilter)
+	}
+
+	streamMap := make([]string, 0, len(bitrates))
+	for i := range bitrates {
+		args = append(args, "-map", "0:a")
+		streamMap = append(streamMap, fmt.Sprintf("a:%d", i))
+	}
+	args = append(args, "-c:a", "aac")
+	for i, bitrate := range bitrates {
+		args = append(args, fmt.Sprintf("-b:a:%d", i), fmt.Sprintf("%dk", bitrate))
+	}
+
+	args = append(args,
+		"-f", "hls",
+		"-hls_time", strconv.Itoa(opts.segmentDuration()),
+		"-hls_playlist_type", "vod",
+		"-hls_segment_type", opts.segmentType(),
+		"-hls_segment_filename", filepath.Join(outputDir, "stream_%v_%05d."+segmentExt),
+	)
+	if opts.segmentType() == HLSSegmentFMP4 {
+		args = append(args, "-hls_fmp4_init_filename", "init_%v.mp4")
+	}
+	args = append(args,
+		"-master_pl_name", models.HLSMasterPlaylist,
+		"-var_stream_map", strings.Join(streamMap, " "),
+		"-y",
+		filepath.Join(outputDir, "stream_%v.m3u8"),
+	)
+
+	cmd := exec.Command("ffmpeg", args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.RemoveAll(outputDir)
+		return nil, fmt.Errorf("ffmpeg hls packaging failed: %w, output: %s", err, string(output))
+	}
+
+	return &models.HLS{
+		Dir:         dirName,
+		SegmentType: opts.segmentType(),
+		Bitrates:    bitrates,
+	}, nil
+}
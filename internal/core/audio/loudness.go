@@ -0,0 +1,133 @@
+package audio
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// replayGainReference ReplayGain 2.0的参考响度 (LUFS)
+const replayGainReference = -18.0
+
+// LoudnessTarget 响度标准化目标
+type LoudnessTarget struct {
+	I   float64 // 综合响度 (LUFS)
+	TP  float64 // 真峰值 (dBTP)
+	LRA float64 // 响度范围 (LU)
+}
+
+// loudnormStats loudnorm滤镜第一遍输出的测量值
+type loudnormStats struct {
+	InputI       string `json:"input_i"`
+	InputTP      string `json:"input_tp"`
+	InputLRA     string `json:"input_lra"`
+	InputThresh  string `json:"input_thresh"`
+	TargetOffset string `json:"target_offset"`
+}
+
+// measureLoudness 第一遍: 测量源音频响度，preFilter为测量前需要应用的滤镜 (如片段截取)
+func measureLoudness(inputPath, preFilter string, target LoudnessTarget) (*loudnormStats, error) {
+	filter := fmt.Sprintf("loudnorm=I=%s:TP=%s:LRA=%s:print_format=json",
+		formatSeconds(target.I), formatSeconds(target.TP), formatSeconds(target.LRA))
+	if preFilter != "" {
+		filter = preFilter + "," + filter
+	}
+
+	cmd := exec.Command("ffmpeg",
+		"-hide_banner",
+		"-nostats",
+		"-i", inputPath,
+		"-af", filter,
+		"-vn",
+		"-f", "null",
+		"-",
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg loudness measurement failed: %w, output: %s", err, string(output))
+	}
+
+	// loudnorm将JSON结果打印在输出末尾
+	text := string(output)
+	start := strings.LastIndex(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("loudnorm output not found")
+	}
+
+	var stats loudnormStats
+	if err := json.Unmarshal([]byte(text[start:end+1]), &stats); err != nil {
+		return nil, fmt.Errorf("failed to parse loudnorm output: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// normalizeFilter 第二遍: 使用测量值进行线性响度标准化，并重采样回48kHz (loudnorm内部会升采样)
+func (t LoudnessTarget) normalizeFilter(stats *loudnormStats) string {
+	return fmt.Sprintf(
+		"loudnorm=I=%s:TP=%s:LRA=%s:measured_I=%s:measured_TP=%s:measured_LRA=%s:measured_thresh=%s:offset=%s:linear=true,aresample=48000",
+		formatSeconds(t.I), formatSeconds(t.TP), formatSeconds(t.LRA),
+		stats.InputI, stats.InputTP, stats.InputLRA, stats.InputThresh, stats.TargetOffset,
+	)
+}
+
+// loudnessInfo 根据测量值计算输出文件的响度信息，normalized为nil表示未做标准化
+func loudnessInfo(stats *loudnormStats, normalized *LoudnessTarget) (*models.Loudness, error) {
+	integrated, err := parseLoudnormValue(stats.InputI)
+	if err != nil {
+		return nil, err
+	}
+	truePeak, err := parseLoudnormValue(stats.InputTP)
+	if err != nil {
+		return nil, err
+	}
+	lra, err := parseLoudnormValue(stats.InputLRA)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &models.Loudness{
+		Integrated: integrated,
+		TruePeak:   truePeak,
+		Range:      lra,
+	}
+
+	// 输出文件的响度: 标准化后约等于目标值，否则与源一致
+	outputI, outputTP := integrated, truePeak
+	if normalized != nil {
+		info.Normalized = true
+		outputI = normalized.I
+		outputTP = math.Min(truePeak+normalized.I-integrated, normalized.TP)
+	}
+
+	info.TrackGain = math.Round((replayGainReference-outputI)*100) / 100
+	info.TrackPeak = math.Round(math.Pow(10, outputTP/20)*1e6) / 1e6
+
+	return info, nil
+}
+
+// replayGainTags 生成ReplayGain元数据标签 (MP3中写入ID3 TXXX帧)
+func replayGainTags(info *models.Loudness) map[string]string {
+	return map[string]string{
+		"REPLAYGAIN_TRACK_GAIN": fmt.Sprintf("%.2f dB", info.TrackGain),
+		"REPLAYGAIN_TRACK_PEAK": fmt.Sprintf("%.6f", info.TrackPeak),
+	}
+}
+
+// parseLoudnormValue 解析loudnorm输出的数值，静音时可能为 "-inf"
+func parseLoudnormValue(value string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid loudnorm value %q: %w", value, err)
+	}
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, fmt.Errorf("audio is silent, loudness cannot be measured")
+	}
+	return v, nil
+}
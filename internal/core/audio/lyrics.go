@@ -0,0 +1,49 @@
+package audio
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LyricLine 一行带时间轴的歌词/字幕
+type LyricLine struct {
+	From    float64 `json:"from"`    // 开始时间(秒)
+	To      float64 `json:"to"`      // 结束时间(秒)
+	Content string  `json:"content"` // 文本
+}
+
+// ToLRC 转换为LRC歌词格式
+func ToLRC(lines []LyricLine) string {
+	var b strings.Builder
+	for _, line := range lines {
+		centis := int64(line.From*100 + 0.5)
+		fmt.Fprintf(&b, "[%02d:%02d.%02d]%s\n",
+			centis/6000, (centis/100)%60, centis%100,
+			strings.ReplaceAll(line.Content, "\n", " "),
+		)
+	}
+	return b.String()
+}
+
+// ToWebVTT 转换为WebVTT字幕格式
+func ToWebVTT(lines []LyricLine) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for i, line := range lines {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n",
+			i+1,
+			vttTimestamp(line.From),
+			vttTimestamp(line.To),
+			line.Content,
+		)
+	}
+	return b.String()
+}
+
+// vttTimestamp 格式化为 hh:mm:ss.mmm
+func vttTimestamp(seconds float64) string {
+	millis := int64(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d",
+		millis/3600000, (millis/60000)%60, (millis/1000)%60, millis%1000,
+	)
+}
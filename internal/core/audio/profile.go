@@ -0,0 +1,173 @@
+package audio
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/config"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 转码配置支持的编码
+const (
+	CodecMP3  = "mp3"
+	CodecAAC  = "aac"
+	CodecOpus = "opus"
+	CodecFLAC = "flac"
+	CodecCopy = "copy" // 不重新编码，直接转封装源音频流
+)
+
+// codecSpec 编码对应的ffmpeg编码器与输出格式
+type codecSpec struct {
+	encoder   string
+	extension string
+}
+
+var codecSpecs = map[string]codecSpec{
+	CodecMP3:  {encoder: "libmp3lame", extension: "mp3"},
+	CodecAAC:  {encoder: "aac", extension: "m4a"},
+	CodecOpus: {encoder: "libopus", extension: "opus"},
+	CodecFLAC: {encoder: "flac", extension: "flac"},
+	CodecCopy: {encoder: "copy"}, // 扩展名按源音频编码选择，见copyExtensions
+}
+
+// 源音频流编码 (DASH codecs)，供没有codecs信息的来源 (音频区) 使用
+const (
+	SourceCodecAAC  = "mp4a.40.2"
+	SourceCodecFLAC = "flac"
+)
+
+// copyExtensions copy配置按源音频编码前缀选择输出封装格式，不在列表中的编码不能转封装
+var copyExtensions = []struct {
+	prefix    string
+	extension string
+}{
+	{prefix: "mp4a", extension: "m4a"}, // AAC
+	{prefix: "flac", extension: "flac"},
+	{prefix: "ec-3", extension: "mka"}, // 杜比 (E-AC-3)，m4a不支持
+	{prefix: "ac-3", extension: "mka"},
+}
+
+// profileNamePattern 配置名会出现在文件名中，只允许安全字符
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// Profile 转码配置
+type Profile struct {
+	Name       string
+	Codec      string // mp3 / aac / opus / flac / copy
+	Bitrate    int    // CBR码率(kbps)，0表示按源码率
+	Quality    *int   // VBR质量 (ffmpeg -q:a)，设置后忽略Bitrate
+	SampleRate int    // 输出采样率，0表示保持源采样率
+	Channels   int    // 输出声道数，0表示保持源声道数
+	Filters    string // 额外的音频滤镜，在片段截取之后、响度标准化之前应用
+}
+
+// DefaultProfile 未指定配置时的默认输出: 按源码率编码为MP3
+var DefaultProfile = Profile{Name: "default", Codec: CodecMP3}
+
+// Validate 校验转码配置
+func (p *Profile) Validate() error {
+	if !profileNamePattern.MatchString(p.Name) {
+		return fmt.Errorf("profile name %q must match %s", p.Name, profileNamePattern)
+	}
+	if _, ok := codecSpecs[p.Codec]; !ok {
+		return fmt.Errorf("profile %s: unsupported codec %q", p.Name, p.Codec)
+	}
+	if p.Bitrate < 0 || p.SampleRate < 0 || p.Channels < 0 {
+		return fmt.Errorf("profile %s: bitrate, sample_rate and channels must not be negative", p.Name)
+	}
+	if p.Codec == CodecCopy && (p.Bitrate > 0 || p.Quality != nil || p.SampleRate > 0 || p.Channels > 0 || p.Filters != "") {
+		return fmt.Errorf("profile %s: copy codec does not accept encoder settings or filters", p.Name)
+	}
+	return nil
+}
+
+// IsCopy 是否为不重新编码的转封装配置
+func (p *Profile) IsCopy() bool {
+	return p.Codec == CodecCopy
+}
+
+// Extension 输出文件扩展名，copy配置按源音频编码选择封装格式
+func (p *Profile) Extension(sourceCodec string) (string, error) {
+	if !p.IsCopy() {
+		return codecSpecs[p.Codec].extension, nil
+	}
+
+	codec := strings.ToLower(sourceCodec)
+	for _, c := range copyExtensions {
+		if strings.HasPrefix(codec, c.prefix) {
+			return c.extension, nil
+		}
+	}
+	return "", fmt.Errorf("profile %s cannot copy %q audio stream", p.Name, sourceCodec)
+}
+
+// Tag 配置内容的摘要，写入缓存变体，修改配置后旧的输出不再命中
+func (p *Profile) Tag() string {
+	quality := ""
+	if p.Quality != nil {
+		quality = strconv.Itoa(*p.Quality)
+	}
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d|%s|%d|%d|%s", p.Codec, p.Bitrate, quality, p.SampleRate, p.Channels, p.Filters)))
+	return p.Name + "@" + hex.EncodeToString(sum[:4])
+}
+
+// outputBitrate 输出文件的标称码率(kbps)
+func (p *Profile) outputBitrate(sourceBitrate int) int {
+	if p.Bitrate > 0 && p.Quality == nil && !p.IsCopy() {
+		return p.Bitrate
+	}
+	return sourceBitrate
+}
+
+// encoderArgs ffmpeg编码参数
+func (p *Profile) encoderArgs(sourceBitrate int) []string {
+	args := []string{"-acodec", codecSpecs[p.Codec].encoder}
+	if p.IsCopy() {
+		return args
+	}
+
+	switch {
+	case p.Quality != nil:
+		args = append(args, "-q:a", strconv.Itoa(*p.Quality))
+	case p.Codec != CodecFLAC:
+		args = append(args, "-ab", fmt.Sprintf("%dk", p.outputBitrate(sourceBitrate)))
+	}
+	if p.SampleRate > 0 {
+		args = append(args, "-ar", strconv.Itoa(p.SampleRate))
+	}
+	if p.Channels > 0 {
+		args = append(args, "-ac", strconv.Itoa(p.Channels))
+	}
+	return args
+}
+
+// NewProfiles 根据配置创建并校验全部转码配置，启动时调用以尽早发现配置错误
+func NewProfiles(cfg config.TranscodeConfig) (map[string]*Profile, error) {
+	profiles := make(map[string]*Profile, len(cfg.Profiles))
+	for name, pc := range cfg.Profiles {
+		profile := &Profile{
+			Name:       name,
+			Codec:      strings.ToLower(pc.Codec),
+			Bitrate:    pc.Bitrate,
+			Quality:    pc.Quality,
+			SampleRate: pc.SampleRate,
+			Channels:   pc.Channels,
+			Filters:    pc.Filters,
+		}
+		if err := profile.Validate(); err != nil {
+			return nil, err
+		}
+		profiles[name] = profile
+	}
+
+	if cfg.DefaultProfile != "" {
+		if _, ok := profiles[cfg.DefaultProfile]; !ok {
+			return nil, fmt.Errorf("default profile %q is not defined", cfg.DefaultProfile)
+		}
+	}
+
+	return profiles, nil
+}
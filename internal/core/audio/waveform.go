@@ -0,0 +1,164 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// WaveformOptions 波形峰值生成选项
+type WaveformOptions struct {
+	SampleRate      int   // 解码采样率
+	SamplesPerPixel []int // 各级分辨率的每像素采样数
+}
+
+// Tag 波形选项的摘要，写入输出文件名与缓存键
+func (o *WaveformOptions) Tag() string {
+	parts := []string{strconv.Itoa(o.sampleRate())}
+	for _, spp := range o.SamplesPerPixel {
+		parts = append(parts, strconv.Itoa(spp))
+	}
+	return strings.Join(parts, "-")
+}
+
+func (o *WaveformOptions) sampleRate() int {
+	if o.SampleRate <= 0 {
+		return 22050
+	}
+	return o.SampleRate
+}
+
+// waveformData audiowaveform兼容的JSON格式 (version 2, 单声道, 8位)
+type waveformData struct {
+	Version         int    `json:"version"`
+	Channels        int    `json:"channels"`
+	SampleRate      int    `json:"sample_rate"`
+	SamplesPerPixel int    `json:"samples_per_pixel"`
+	Bits            int    `json:"bits"`
+	Length          int    `json:"length"`
+	Data            []int8 `json:"data"`
+}
+
+// waveformLevel 单个分辨率的累积状态
+type waveformLevel struct {
+	spp      int
+	count    int
+	min, max int16
+	data     []int8
+}
+
+func (l *waveformLevel) add(sample int16) {
+	if l.count == 0 || sample < l.min {
+		l.min = sample
+	}
+	if l.count == 0 || sample > l.max {
+		l.max = sample
+	}
+	l.count++
+	if l.count == l.spp {
+		l.flush()
+	}
+}
+
+func (l *waveformLevel) flush() {
+	if l.count == 0 {
+		return
+	}
+	l.data = append(l.data, int8(l.min>>8), int8(l.max>>8))
+	l.count = 0
+}
+
+// generateWaveforms 解码音频文件并一次性计算多个分辨率的峰值，保存为 outDir 下的 <baseName>.waveform.<spp>.json
+func (d *Downloader) generateWaveforms(audioPath, outDir, baseName string, opts WaveformOptions) ([]models.Waveform, error) {
+	if len(opts.SamplesPerPixel) == 0 {
+		return nil, nil
+	}
+
+	sampleRate := opts.sampleRate()
+
+	// 解码为单声道16位PCM
+	cmd := exec.Command("ffmpeg",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-i", audioPath,
+		"-ac", "1",
+		"-ar", strconv.Itoa(sampleRate),
+		"-f", "s16le",
+		"-",
+	)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ffmpeg pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	levels := make([]*waveformLevel, 0, len(opts.SamplesPerPixel))
+	for _, spp := range opts.SamplesPerPixel {
+		if spp > 0 {
+			levels = append(levels, &waveformLevel{spp: spp})
+		}
+	}
+
+	reader := bufio.NewReaderSize(stdout, 64*1024)
+	buf := make([]byte, 2)
+	for {
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			break
+		}
+		sample := int16(binary.LittleEndian.Uint16(buf))
+		for _, level := range levels {
+			level.add(sample)
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("ffmpeg decode failed: %w", err)
+	}
+
+	waveforms := make([]models.Waveform, 0, len(levels))
+	for _, level := range levels {
+		level.flush()
+
+		data, err := json.Marshal(waveformData{
+			Version:         2,
+			Channels:        1,
+			SampleRate:      sampleRate,
+			SamplesPerPixel: level.spp,
+			Bits:            8,
+			Length:          len(level.data) / 2,
+			Data:            level.data,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal waveform: %w", err)
+		}
+
+		fileName := fmt.Sprintf("%s.waveform.%d.json", baseName, level.spp)
+		if err := utils.WriteFileAtomic(utils.StagingDir(d.cacheDir), filepath.Join(outDir, fileName), data, 0644); err != nil {
+			// 清理已写入的其他分辨率
+			for _, w := range waveforms {
+				os.Remove(filepath.Join(outDir, w.FileName))
+			}
+			return nil, fmt.Errorf("failed to write waveform file: %w", err)
+		}
+
+		waveforms = append(waveforms, models.Waveform{
+			SamplesPerPixel: level.spp,
+			SampleRate:      sampleRate,
+			Length:          len(level.data) / 2,
+			FileName:        fileName,
+		})
+	}
+
+	return waveforms, nil
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// keyPrefix 生成的访问密钥前缀，便于在配置和代码中辨认
+	keyPrefix = "bap_"
+	// keyBytes 访问密钥的随机字节数
+	keyBytes = 24
+	// lastUsedInterval 最近使用时间的更新间隔，避免每个请求都写数据库
+	lastUsedInterval = time.Minute
+)
+
+// Identity 通过鉴权的调用方
+type Identity struct {
+	Name  string `json:"name"`             // 静态令牌为 "static"，访问密钥为其名称
+	KeyID uint   `json:"key_id,omitempty"` // 访问密钥ID，静态令牌为0
+}
+
+// Authenticator 校验访问令牌：配置文件中的静态令牌，以及数据库中管理的访问密钥
+type Authenticator struct {
+	tokens [][]byte
+	db     *gorm.DB
+}
+
+// NewAuthenticator 创建鉴权器，tokens为配置文件中的静态令牌
+func NewAuthenticator(tokens []string, db *gorm.DB) *Authenticator {
+	a := &Authenticator{db: db}
+	for _, token := range tokens {
+		if token != "" {
+			a.tokens = append(a.tokens, []byte(token))
+		}
+	}
+	return a
+}
+
+// Authenticate 校验令牌，无效时返回nil
+func (a *Authenticator) Authenticate(token string) (*Identity, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	// 逐个比较全部静态令牌，耗时与匹配位置无关
+	matched := 0
+	for _, candidate := range a.tokens {
+		matched |= subtle.ConstantTimeCompare([]byte(token), candidate)
+	}
+	if matched == 1 {
+		return &Identity{Name: "static"}, nil
+	}
+
+	// 数据库中只有摘要，按摘要查询不泄露密钥内容
+	var key models.APIKey
+	result := a.db.Where("key_hash = ? AND revoked_at IS NULL", HashKey(token)).Limit(1).Find(&key)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query api key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedInterval {
+		if err := a.db.Model(&key).UpdateColumn("last_used_at", now).Error; err != nil {
+			fmt.Printf("Warning: failed to update api key %d last used time: %v\n", key.ID, err)
+		}
+	}
+	return &Identity{Name: key.Name, KeyID: key.ID}, nil
+}
+
+// CreateKey 生成新的访问密钥，返回明文密钥 (只在此时可见) 及数据库记录
+func (a *Authenticator) CreateKey(name string) (string, *models.APIKey, error) {
+	random := make([]byte, keyBytes)
+	if _, err := rand.Read(random); err != nil {
+		return "", nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	plain := keyPrefix + hex.EncodeToString(random)
+
+	key := &models.APIKey{
+		Name:    name,
+		Prefix:  plain[:len(keyPrefix)+6],
+		KeyHash: HashKey(plain),
+	}
+	if err := a.db.Create(key).Error; err != nil {
+		return "", nil, fmt.Errorf("failed to save api key: %w", err)
+	}
+	return plain, key, nil
+}
+
+// ListKeys 列出全部访问密钥 (含已吊销的)，新创建的在前
+func (a *Authenticator) ListKeys() ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := a.db.Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeKey 吊销访问密钥，立即生效；不存在时返回nil，已吊销时保持原吊销时间
+func (a *Authenticator) RevokeKey(id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	result := a.db.Where("id = ?", id).Limit(1).Find(&key)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to find api key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	if key.RevokedAt != nil {
+		return &key, nil
+	}
+
+	now := time.Now()
+	if err := a.db.Model(&key).UpdateColumn("revoked_at", now).Error; err != nil {
+		return nil, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	key.RevokedAt = &now
+	return &key, nil
+}
+
+// HashKey 访问密钥的SHA-256摘要 (十六进制)，密钥为高熵随机值，无需加盐
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,229 @@
+package bilibili
+
+import (
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/audio"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	audioZoneAPI = "https://www.bilibili.com/audio/music-service-c/web"
+
+	menuPageSize = 100 // 歌单每页歌曲数
+	menuMaxPages = 20  // 最多翻页数
+)
+
+// 音频区音质代码
+const (
+	SongQuality128K = 0 // 128K
+	SongQuality192K = 1 // 192K
+	SongQuality320K = 2 // 320K
+	SongQualityFLAC = 3 // 无损 (需要大会员)
+)
+
+// songQualityBitrate 音质代码对应的MP3转码比特率 (kbps)
+var songQualityBitrate = map[int]int{
+	SongQuality128K: 128,
+	SongQuality192K: 192,
+	SongQuality320K: 320,
+	SongQualityFLAC: 320,
+}
+
+var (
+	songIDPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bau(\d+)`),
+		regexp.MustCompile(`[?&](?:music_id|sid)=(\d+)`),
+	}
+	menuIDPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bam(\d+)`),
+		regexp.MustCompile(`[?&](?:menu_id|menuId)=(\d+)`),
+	}
+)
+
+// ParseSongID 从au号或音频区链接中解析歌曲ID
+// 支持: 123456 / au123456 / https://www.bilibili.com/audio/au123456 / https://music.bilibili.com/h5/music-detail?music_id=123456
+func ParseSongID(input string) (int64, error) {
+	return parseAudioZoneID(input, songIDPatterns)
+}
+
+// ParseMenuID 从am号或音频区链接中解析歌单ID
+// 支持: 123456 / am123456 / https://www.bilibili.com/audio/am123456
+func ParseMenuID(input string) (int64, error) {
+	return parseAudioZoneID(input, menuIDPatterns)
+}
+
+func parseAudioZoneID(input string, patterns []*regexp.Regexp) (int64, error) {
+	input = strings.TrimSpace(input)
+	if id, err := strconv.ParseInt(input, 10, 64); err == nil && id > 0 {
+		return id, nil
+	}
+
+	for _, pattern := range patterns {
+		if m := pattern.FindStringSubmatch(input); m != nil {
+			if id, err := strconv.ParseInt(m[1], 10, 64); err == nil && id > 0 {
+				return id, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("invalid audio zone id or url: %s", input)
+}
+
+// SongKey 音频区歌曲在缓存和文件名中使用的标识
+func SongKey(sid int64) string {
+	return "au" + strconv.FormatInt(sid, 10)
+}
+
+// ParseSong 解析音频区歌曲，返回与视频音频相同结构的AudioInfo
+// quality为音频区音质代码，无效值 (如-1) 时使用320K
+func (p *AudioParser) ParseSong(sid int64, quality int, opts audio.ConvertOptions) (*models.AudioInfo, error) {
+	if _, ok := songQualityBitrate[quality]; !ok {
+		quality = SongQuality320K
+	}
+
+	// 1. 获取歌曲信息
+	info, err := p.getSongInfo(sid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get song info: %w", err)
+	}
+
+	// 2. 获取播放地址
+	streamURL, err := p.getSongURL(sid, quality)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get song URL: %w", err)
+	}
+
+	// 3. 下载并转换音频，无损音质为FLAC，其余为AAC
+	codec := audio.SourceCodecAAC
+	if quality == SongQualityFLAC {
+		codec = audio.SourceCodecFLAC
+	}
+	audioInfo, err := p.downloader.DownloadAndConvert(
+		SongKey(sid),
+		quality,
+		codec,
+		streamURL,
+		songQualityBitrate[quality],
+		info.Duration,
+		opts,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download and convert audio: %w", err)
+	}
+
+	audioInfo.Title = info.Title
+	audioInfo.Artist = info.Author
+	if audioInfo.Artist == "" {
+		audioInfo.Artist = info.Uname
+	}
+	audioInfo.Cover = info.Cover
+	audioInfo.LyricURL = info.Lyric
+	audioInfo.OriginalURLExpires = originalURLExpires(audioInfo.OriginalURL)
+
+	return audioInfo, nil
+}
+
+// getSongInfo 获取音频区歌曲信息
+func (p *AudioParser) getSongInfo(sid int64) (*SongDetail, error) {
+	var resp SongInfoResponse
+	if err := p.getJSON(audioZoneAPI+"/song/info?sid="+strconv.FormatInt(sid, 10), &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Code != 0 || resp.Data == nil {
+		return nil, fmt.Errorf("song info API returned error: code=%d, message=%s", resp.Code, resp.Msg)
+	}
+
+	return resp.Data, nil
+}
+
+// getSongURL 获取音频区歌曲播放地址
+func (p *AudioParser) getSongURL(sid int64, quality int) (string, error) {
+	params := url.Values{}
+	params.Set("sid", strconv.FormatInt(sid, 10))
+	params.Set("privilege", "2")
+	params.Set("quality", strconv.Itoa(quality))
+
+	var resp SongURLResponse
+	if err := p.getJSON(audioZoneAPI+"/url?"+params.Encode(), &resp); err != nil {
+		return "", err
+	}
+
+	if resp.Code != 0 || resp.Data == nil {
+		return "", fmt.Errorf("song url API returned error: code=%d, message=%s", resp.Code, resp.Msg)
+	}
+
+	if len(resp.Data.CDNs) == 0 {
+		return "", fmt.Errorf("no audio streams found")
+	}
+
+	return resp.Data.CDNs[0], nil
+}
+
+// ResolveMenu 获取音频区歌单 (am号) 的有序曲目列表
+func (p *AudioParser) ResolveMenu(menuID int64) (*models.Playlist, error) {
+	if menuID <= 0 {
+		return nil, fmt.Errorf("invalid menu id: %d", menuID)
+	}
+
+	var info MenuInfoResponse
+	if err := p.getJSON(audioZoneAPI+"/menu/info?sid="+strconv.FormatInt(menuID, 10), &info); err != nil {
+		return nil, fmt.Errorf("failed to get menu info: %w", err)
+	}
+
+	if info.Code != 0 || info.Data == nil {
+		return nil, fmt.Errorf("menu info API returned error: code=%d, message=%s", info.Code, info.Msg)
+	}
+
+	playlist := &models.Playlist{
+		Type:        models.PlaylistTypeMenu,
+		ID:          menuID,
+		Title:       info.Data.Title,
+		Cover:       info.Data.Cover,
+		Description: info.Data.Intro,
+		Owner:       &models.PlaylistOwner{MID: info.Data.UID, Name: info.Data.Uname},
+		Tracks:      []models.Track{},
+	}
+
+	for page := 1; page <= menuMaxPages; page++ {
+		params := url.Values{}
+		params.Set("sid", strconv.FormatInt(menuID, 10))
+		params.Set("pn", strconv.Itoa(page))
+		params.Set("ps", strconv.Itoa(menuPageSize))
+
+		var resp MenuSongsResponse
+		if err := p.getJSON(audioZoneAPI+"/song/of-menu?"+params.Encode(), &resp); err != nil {
+			return nil, fmt.Errorf("failed to get menu songs: %w", err)
+		}
+
+		if resp.Code != 0 || resp.Data == nil {
+			return nil, fmt.Errorf("menu songs API returned error: code=%d, message=%s", resp.Code, resp.Msg)
+		}
+
+		for _, song := range resp.Data.Data {
+			playlist.Tracks = append(playlist.Tracks, models.Track{
+				Index:    len(playlist.Tracks) + 1,
+				BVID:     song.BVID,
+				AID:      song.AID,
+				SID:      song.ID,
+				CID:      song.CID,
+				Title:    song.Title,
+				Artist:   song.Author,
+				Cover:    song.Cover,
+				Duration: song.Duration,
+			})
+		}
+
+		if len(resp.Data.Data) == 0 || page >= resp.Data.PageCount {
+			break
+		}
+	}
+
+	playlist.Total = len(playlist.Tracks)
+
+	return playlist, nil
+}
@@ -0,0 +1,188 @@
+package bilibili
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"net/url"
+	"strconv"
+)
+
+const (
+	collectionPageSize = 100 // 每页稿件数 (接口上限)
+	collectionMaxPages = 50  // 最多翻页数，避免超大合集拖慢请求
+)
+
+// ErrNotInCollection 视频不属于任何合集
+var ErrNotInCollection = errors.New("video is not part of a collection")
+
+// ResolveSeasonByBV 通过BV号检测所属合集并返回合集曲目列表
+func (p *AudioParser) ResolveSeasonByBV(bvid string) (*models.Playlist, error) {
+	videoInfo, err := p.getVideoInfo(bvid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video info: %w", err)
+	}
+
+	season := videoInfo.Data.UgcSeason
+	if season == nil || season.ID == 0 {
+		return nil, ErrNotInCollection
+	}
+
+	playlist := &models.Playlist{
+		Type:        models.PlaylistTypeSeason,
+		ID:          season.ID,
+		Title:       season.Title,
+		Cover:       season.Cover,
+		Description: season.Intro,
+		Owner:       &models.PlaylistOwner{MID: season.MID},
+		Tracks:      []models.Track{},
+	}
+
+	// view接口已返回完整的分节与剧集，按顺序展开即可
+	for _, section := range season.Sections {
+		for _, ep := range section.Episodes {
+			playlist.Tracks = append(playlist.Tracks, models.Track{
+				Index:    len(playlist.Tracks) + 1,
+				BVID:     ep.BVID,
+				AID:      ep.AID,
+				CID:      ep.CID,
+				Title:    ep.Title,
+				Cover:    ep.Arc.Pic,
+				Duration: ep.Arc.Duration,
+			})
+		}
+	}
+	playlist.Total = len(playlist.Tracks)
+
+	return playlist, nil
+}
+
+// ResolveSeason 获取合集 (ugc_season) 的有序曲目列表
+func (p *AudioParser) ResolveSeason(mid, seasonID int64) (*models.Playlist, error) {
+	if mid <= 0 || seasonID <= 0 {
+		return nil, fmt.Errorf("invalid season: mid=%d, season_id=%d", mid, seasonID)
+	}
+
+	playlist := &models.Playlist{
+		Type:   models.PlaylistTypeSeason,
+		ID:     seasonID,
+		Owner:  &models.PlaylistOwner{MID: mid},
+		Tracks: []models.Track{},
+	}
+
+	for page := 1; page <= collectionMaxPages; page++ {
+		params := url.Values{}
+		params.Set("mid", strconv.FormatInt(mid, 10))
+		params.Set("season_id", strconv.FormatInt(seasonID, 10))
+		params.Set("sort_reverse", "false")
+		params.Set("page_num", strconv.Itoa(page))
+		params.Set("page_size", strconv.Itoa(collectionPageSize))
+
+		var resp SeasonArchivesResponse
+		if err := p.getJSON("https://api.bilibili.com/x/polymer/web-space/seasons_archives_list?"+params.Encode(), &resp); err != nil {
+			return nil, fmt.Errorf("failed to get season archives: %w", err)
+		}
+
+		if resp.Code != 0 {
+			return nil, fmt.Errorf("season archives API returned error: code=%d, message=%s", resp.Code, resp.Message)
+		}
+
+		if page == 1 {
+			playlist.Title = resp.Data.Meta.Name
+			playlist.Cover = resp.Data.Meta.Cover
+			playlist.Description = resp.Data.Meta.Description
+			playlist.Total = resp.Data.Page.Total
+		}
+
+		appendArchives(playlist, resp.Data.Archives)
+
+		if len(resp.Data.Archives) == 0 || len(playlist.Tracks) >= resp.Data.Page.Total {
+			break
+		}
+	}
+
+	if playlist.Total == 0 {
+		playlist.Total = len(playlist.Tracks)
+	}
+
+	return playlist, nil
+}
+
+// ResolveSeries 获取系列的有序曲目列表，mid为0时从系列信息中获取
+func (p *AudioParser) ResolveSeries(mid, seriesID int64) (*models.Playlist, error) {
+	if seriesID <= 0 {
+		return nil, fmt.Errorf("invalid series_id: %d", seriesID)
+	}
+
+	var meta SeriesMetaResponse
+	if err := p.getJSON("https://api.bilibili.com/x/series/series?series_id="+strconv.FormatInt(seriesID, 10), &meta); err != nil {
+		return nil, fmt.Errorf("failed to get series meta: %w", err)
+	}
+
+	if meta.Code != 0 {
+		return nil, fmt.Errorf("series meta API returned error: code=%d, message=%s", meta.Code, meta.Message)
+	}
+
+	if mid <= 0 {
+		mid = meta.Data.Meta.MID
+	}
+
+	playlist := &models.Playlist{
+		Type:        models.PlaylistTypeSeries,
+		ID:          seriesID,
+		Title:       meta.Data.Meta.Name,
+		Description: meta.Data.Meta.Description,
+		Owner:       &models.PlaylistOwner{MID: mid},
+		Total:       meta.Data.Meta.Total,
+		Tracks:      []models.Track{},
+	}
+
+	for page := 1; page <= collectionMaxPages; page++ {
+		params := url.Values{}
+		params.Set("mid", strconv.FormatInt(mid, 10))
+		params.Set("series_id", strconv.FormatInt(seriesID, 10))
+		params.Set("only_normal", "true")
+		params.Set("sort", "asc")
+		params.Set("pn", strconv.Itoa(page))
+		params.Set("ps", strconv.Itoa(collectionPageSize))
+
+		var resp SeriesArchivesResponse
+		if err := p.getJSON("https://api.bilibili.com/x/series/archives?"+params.Encode(), &resp); err != nil {
+			return nil, fmt.Errorf("failed to get series archives: %w", err)
+		}
+
+		if resp.Code != 0 {
+			return nil, fmt.Errorf("series archives API returned error: code=%d, message=%s", resp.Code, resp.Message)
+		}
+
+		appendArchives(playlist, resp.Data.Archives)
+
+		if len(resp.Data.Archives) == 0 || len(playlist.Tracks) >= resp.Data.Page.Total {
+			break
+		}
+	}
+
+	// 系列封面使用第一个稿件的封面
+	if len(playlist.Tracks) > 0 {
+		playlist.Cover = playlist.Tracks[0].Cover
+	}
+	if playlist.Total == 0 {
+		playlist.Total = len(playlist.Tracks)
+	}
+
+	return playlist, nil
+}
+
+// appendArchives 将稿件追加为曲目，保持接口返回顺序
+func appendArchives(playlist *models.Playlist, archives []ArchiveItem) {
+	for _, archive := range archives {
+		playlist.Tracks = append(playlist.Tracks, models.Track{
+			Index:    len(playlist.Tracks) + 1,
+			BVID:     archive.BVID,
+			AID:      archive.AID,
+			Title:    archive.Title,
+			Cover:    archive.Pic,
+			Duration: archive.Duration,
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package bilibili
+
+import (
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	favoritePageSize = 20  // 收藏夹接口每页上限
+	favoriteMaxPages = 100 // 最多翻页数 (约2000条)
+
+	favoriteMediaTypeVideo = 2 // 收藏内容类型: 视频稿件
+
+	favoriteCodePrivate = -403 // 收藏夹未公开
+)
+
+var (
+	favoriteFidPattern = regexp.MustCompile(`[?&]fid=(\d+)`)
+	favoriteMlPattern  = regexp.MustCompile(`/ml(\d+)`)
+)
+
+// ParseFavoriteID 从media_id或收藏夹链接中解析收藏夹ID
+// 支持: 123456 / ml123456 / https://space.bilibili.com/1/favlist?fid=123456 / https://www.bilibili.com/medialist/detail/ml123456
+func ParseFavoriteID(input string) (int64, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return 0, fmt.Errorf("empty favorite id")
+	}
+
+	raw := strings.TrimPrefix(input, "ml")
+	if id, err := strconv.ParseInt(raw, 10, 64); err == nil && id > 0 {
+		return id, nil
+	}
+
+	for _, pattern := range []*regexp.Regexp{favoriteFidPattern, favoriteMlPattern} {
+		if m := pattern.FindStringSubmatch(input); m != nil {
+			if id, err := strconv.ParseInt(m[1], 10, 64); err == nil && id > 0 {
+				return id, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("invalid favorite id or url: %s", input)
+}
+
+// ResolveFavorite 获取公开收藏夹内容，仅保留可播放的视频稿件
+func (p *AudioParser) ResolveFavorite(mediaID int64) (*models.Playlist, error) {
+	if mediaID <= 0 {
+		return nil, fmt.Errorf("invalid media_id: %d", mediaID)
+	}
+
+	playlist := &models.Playlist{
+		Type:   models.PlaylistTypeFavorite,
+		ID:     mediaID,
+		Tracks: []models.Track{},
+	}
+
+	for page := 1; page <= favoriteMaxPages; page++ {
+		params := url.Values{}
+		params.Set("media_id", strconv.FormatInt(mediaID, 10))
+		params.Set("pn", strconv.Itoa(page))
+		params.Set("ps", strconv.Itoa(favoritePageSize))
+		params.Set("order", "mtime")
+		params.Set("platform", "web")
+
+		var resp FavoriteListResponse
+		if err := p.getJSON("https://api.bilibili.com/x/v3/fav/resource/list?"+params.Encode(), &resp); err != nil {
+			return nil, fmt.Errorf("failed to get favorite list: %w", err)
+		}
+
+		if resp.Code == favoriteCodePrivate {
+			return nil, fmt.Errorf("%w: favorite is private: code=%d, message=%s", ErrPermissionDenied, resp.Code, resp.Message)
+		}
+		if resp.Code != 0 {
+			return nil, fmt.Errorf("favorite list API returned error: code=%d, message=%s", resp.Code, resp.Message)
+		}
+
+		if page == 1 {
+			info := resp.Data.Info
+			playlist.Title = info.Title
+			playlist.Cover = info.Cover
+			playlist.Description = info.Intro
+			playlist.Owner = &models.PlaylistOwner{
+				MID:  info.Upper.MID,
+				Name: info.Upper.Name,
+				Face: info.Upper.Face,
+			}
+		}
+
+		for _, media := range resp.Data.Medias {
+			// 跳过音频、合集等非视频内容，以及已失效 (attr非0) 的稿件
+			if media.Type != favoriteMediaTypeVideo || media.Attr != 0 || media.BVID == "" {
+				continue
+			}
+
+			track := models.Track{
+				Index:    len(playlist.Tracks) + 1,
+				BVID:     media.BVID,
+				AID:      media.ID,
+				Title:    media.Title,
+				Cover:    media.Cover,
+				Duration: media.Duration,
+			}
+			if media.Ugc != nil {
+				track.CID = media.Ugc.FirstCID
+			}
+			playlist.Tracks = append(playlist.Tracks, track)
+		}
+
+		if !resp.Data.HasMore {
+			break
+		}
+	}
+
+	playlist.Total = len(playlist.Tracks)
+
+	return playlist, nil
+}
@@ -24,7 +24,13 @@ type VideoInfoResponse struct {
 		BVID  string `json:"bvid"`
 		AID   int64  `json:"aid"`
 		Title string `json:"title"`
+		Pic   string `json:"pic"`
 		CID   int64  `json:"cid"`
+		Owner struct {
+			MID  int64  `json:"mid"`
+			Name string `json:"name"`
+			Face string `json:"face"`
+		} `json:"owner"`
 		Pages []struct {
 			CID   int64  `json:"cid"`
 			Page  int    `json:"page"`
@@ -32,43 +38,315 @@ type VideoInfoResponse struct {
 			Part  string `json:"part"`
 			Index int    `json:"index"`
 		} `json:"pages"`
+		UgcSeason *UgcSeason `json:"ugc_season,omitempty"`
+	} `json:"data"`
+}
+
+// UgcSeason 视频所属合集信息
+type UgcSeason struct {
+	ID       int64  `json:"id"`
+	Title    string `json:"title"`
+	Cover    string `json:"cover"`
+	MID      int64  `json:"mid"`
+	Intro    string `json:"intro"`
+	EpCount  int    `json:"ep_count"`
+	Sections []struct {
+		ID       int64  `json:"id"`
+		Title    string `json:"title"`
+		Episodes []struct {
+			AID   int64  `json:"aid"`
+			BVID  string `json:"bvid"`
+			CID   int64  `json:"cid"`
+			Title string `json:"title"`
+			Arc   struct {
+				Pic      string `json:"pic"`
+				Duration int    `json:"duration"`
+			} `json:"arc"`
+		} `json:"episodes"`
+	} `json:"sections"`
+}
+
+// ArchiveItem 合集/系列中的稿件
+type ArchiveItem struct {
+	AID      int64  `json:"aid"`
+	BVID     string `json:"bvid"`
+	Title    string `json:"title"`
+	Pic      string `json:"pic"`
+	Duration int    `json:"duration"`
+}
+
+// SeasonArchivesResponse 合集稿件列表响应
+type SeasonArchivesResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	TTL     int    `json:"ttl"`
+	Data    struct {
+		Archives []ArchiveItem `json:"archives"`
+		Meta     struct {
+			SeasonID    int64  `json:"season_id"`
+			Name        string `json:"name"`
+			Cover       string `json:"cover"`
+			Description string `json:"description"`
+			MID         int64  `json:"mid"`
+			Total       int    `json:"total"`
+		} `json:"meta"`
+		Page struct {
+			PageNum  int `json:"page_num"`
+			PageSize int `json:"page_size"`
+			Total    int `json:"total"`
+		} `json:"page"`
+	} `json:"data"`
+}
+
+// SeriesMetaResponse 系列信息响应
+type SeriesMetaResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	TTL     int    `json:"ttl"`
+	Data    struct {
+		Meta struct {
+			SeriesID    int64  `json:"series_id"`
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			MID         int64  `json:"mid"`
+			Total       int    `json:"total"`
+		} `json:"meta"`
+	} `json:"data"`
+}
+
+// SeriesArchivesResponse 系列稿件列表响应
+type SeriesArchivesResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	TTL     int    `json:"ttl"`
+	Data    struct {
+		Archives []ArchiveItem `json:"archives"`
+		Page     struct {
+			Num   int `json:"num"`
+			Size  int `json:"size"`
+			Total int `json:"total"`
+		} `json:"page"`
 	} `json:"data"`
 }
 
 // PlayURLResponse 播放地址响应
 type PlayURLResponse struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	TTL     int         `json:"ttl"`
+	Data    PlayURLData `json:"data"`
+}
+
+// PlayURLData 播放地址数据 (UGC与PGC接口共用)
+type PlayURLData struct {
+	Quality     int    `json:"quality"`
+	Format      string `json:"format"`
+	Timelength  int    `json:"timelength"`
+	VideoCodeid int    `json:"video_codecid"`
+	Dash        *struct {
+		Duration int `json:"duration"`
+		Audio    []struct {
+			ID        int      `json:"id"`
+			BaseURL   string   `json:"baseUrl"`
+			BackupURL []string `json:"backupUrl"`
+			Bandwidth int      `json:"bandwidth"`
+			MimeType  string   `json:"mimeType"`
+			Codecs    string   `json:"codecs"`
+			Width     int      `json:"width,omitempty"`
+			Height    int      `json:"height,omitempty"`
+			FrameRate string   `json:"frameRate,omitempty"`
+		} `json:"audio"`
+		Video []struct {
+			ID        int      `json:"id"`
+			BaseURL   string   `json:"baseUrl"`
+			BackupURL []string `json:"backupUrl"`
+			Bandwidth int      `json:"bandwidth"`
+			MimeType  string   `json:"mimeType"`
+			Codecs    string   `json:"codecs"`
+			Width     int      `json:"width"`
+			Height    int      `json:"height"`
+			FrameRate string   `json:"frameRate"`
+		} `json:"video"`
+	} `json:"dash"`
+}
+
+// FavoriteListResponse 收藏夹内容列表响应
+type FavoriteListResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	TTL     int    `json:"ttl"`
+	Data    struct {
+		Info struct {
+			ID         int64  `json:"id"`
+			Title      string `json:"title"`
+			Cover      string `json:"cover"`
+			Intro      string `json:"intro"`
+			MediaCount int    `json:"media_count"`
+			Upper      struct {
+				MID  int64  `json:"mid"`
+				Name string `json:"name"`
+				Face string `json:"face"`
+			} `json:"upper"`
+		} `json:"info"`
+		Medias []struct {
+			ID       int64  `json:"id"`
+			Type     int    `json:"type"`
+			Title    string `json:"title"`
+			Cover    string `json:"cover"`
+			Duration int    `json:"duration"`
+			Attr     int    `json:"attr"`
+			BVID     string `json:"bvid"`
+			Ugc      *struct {
+				FirstCID int64 `json:"first_cid"`
+			} `json:"ugc,omitempty"`
+		} `json:"medias"`
+		HasMore bool `json:"has_more"`
+	} `json:"data"`
+}
+
+// SongDetail 音频区歌曲详情
+type SongDetail struct {
+	ID       int64  `json:"id"`
+	UID      int64  `json:"uid"`
+	Uname    string `json:"uname"`
+	Author   string `json:"author"`
+	Title    string `json:"title"`
+	Cover    string `json:"cover"`
+	Intro    string `json:"intro"`
+	Lyric    string `json:"lyric"`
+	Duration int    `json:"duration"`
+	BVID     string `json:"bvid"`
+	AID      int64  `json:"aid"`
+	CID      int64  `json:"cid"`
+}
+
+// SongInfoResponse 音频区歌曲信息响应
+type SongInfoResponse struct {
+	Code int         `json:"code"`
+	Msg  string      `json:"msg"`
+	Data *SongDetail `json:"data"`
+}
+
+// SongURLResponse 音频区歌曲播放地址响应
+type SongURLResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	Data *struct {
+		SID     int64    `json:"sid"`
+		Type    int      `json:"type"`
+		Timeout int      `json:"timeout"`
+		Size    int64    `json:"size"`
+		CDNs    []string `json:"cdns"`
+	} `json:"data"`
+}
+
+// SongItem 歌单中的歌曲
+type SongItem struct {
+	ID       int64  `json:"id"`
+	Title    string `json:"title"`
+	Cover    string `json:"cover"`
+	Author   string `json:"author"`
+	Duration int    `json:"duration"`
+	BVID     string `json:"bvid"`
+	AID      int64  `json:"aid"`
+	CID      int64  `json:"cid"`
+}
+
+// MenuInfoResponse 音频区歌单信息响应
+type MenuInfoResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	Data *struct {
+		MenuID int64  `json:"menuId"`
+		UID    int64  `json:"uid"`
+		Uname  string `json:"uname"`
+		Title  string `json:"title"`
+		Cover  string `json:"cover"`
+		Intro  string `json:"intro"`
+	} `json:"data"`
+}
+
+// MenuSongsResponse 音频区歌单歌曲列表响应
+type MenuSongsResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	Data *struct {
+		CurPage   int        `json:"curPage"`
+		PageCount int        `json:"pageCount"`
+		TotalSize int        `json:"totalSize"`
+		PageSize  int        `json:"pageSize"`
+		Data      []SongItem `json:"data"`
+	} `json:"data"`
+}
+
+// PGCSeasonResponse 番剧/影视剧集信息响应
+type PGCSeasonResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Result  *struct {
+		SeasonID int64        `json:"season_id"`
+		Title    string       `json:"title"`
+		Cover    string       `json:"cover"`
+		Evaluate string       `json:"evaluate"`
+		Episodes []PGCEpisode `json:"episodes"`
+	} `json:"result"`
+}
+
+// PGCEpisode 番剧/影视单集
+type PGCEpisode struct {
+	ID        int64  `json:"id"`
+	AID       int64  `json:"aid"`
+	BVID      string `json:"bvid"`
+	CID       int64  `json:"cid"`
+	Title     string `json:"title"`
+	LongTitle string `json:"long_title"`
+	Cover     string `json:"cover"`
+	Duration  int    `json:"duration"` // 毫秒
+}
+
+// PGCPlayURLResponse 番剧/影视播放地址响应
+type PGCPlayURLResponse struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Result  *PGCPlayURLData `json:"result"`
+}
+
+// PGCPlayURLData PGC播放地址数据
+type PGCPlayURLData struct {
+	PlayURLData
+	IsPreview int `json:"is_preview"`
+}
+
+// PlayerInfoResponse 播放器信息响应 (含字幕列表)
+type PlayerInfoResponse struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 	TTL     int    `json:"ttl"`
 	Data    struct {
-		Quality     int    `json:"quality"`
-		Format      string `json:"format"`
-		Timelength  int    `json:"timelength"`
-		VideoCodeid int    `json:"video_codecid"`
-		Dash        *struct {
-			Duration int `json:"duration"`
-			Audio    []struct {
-				ID        int      `json:"id"`
-				BaseURL   string   `json:"baseUrl"`
-				BackupURL []string `json:"backupUrl"`
-				Bandwidth int      `json:"bandwidth"`
-				MimeType  string   `json:"mimeType"`
-				Codecs    string   `json:"codecs"`
-				Width     int      `json:"width,omitempty"`
-				Height    int      `json:"height,omitempty"`
-				FrameRate string   `json:"frameRate,omitempty"`
-			} `json:"audio"`
-			Video []struct {
-				ID        int      `json:"id"`
-				BaseURL   string   `json:"baseUrl"`
-				BackupURL []string `json:"backupUrl"`
-				Bandwidth int      `json:"bandwidth"`
-				MimeType  string   `json:"mimeType"`
-				Codecs    string   `json:"codecs"`
-				Width     int      `json:"width"`
-				Height    int      `json:"height"`
-				FrameRate string   `json:"frameRate"`
-			} `json:"video"`
-		} `json:"dash"`
+		ViewPoints []struct {
+			Type    int    `json:"type"`
+			From    int    `json:"from"`
+			To      int    `json:"to"`
+			Content string `json:"content"`
+		} `json:"view_points"`
+		Subtitle struct {
+			Subtitles []struct {
+				ID          int64  `json:"id"`
+				Lan         string `json:"lan"`
+				LanDoc      string `json:"lan_doc"`
+				SubtitleURL string `json:"subtitle_url"`
+				AIType      int    `json:"ai_type"`
+				AIStatus    int    `json:"ai_status"`
+			} `json:"subtitles"`
+		} `json:"subtitle"`
 	} `json:"data"`
 }
+
+// SubtitleBody B站JSON字幕文件
+type SubtitleBody struct {
+	Body []struct {
+		From    float64 `json:"from"`
+		To      float64 `json:"to"`
+		Content string  `json:"content"`
+	} `json:"body"`
+}
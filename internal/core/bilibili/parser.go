@@ -2,13 +2,18 @@ package bilibili
 
 import (
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/audio"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/storage"
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
 // AudioParser B站音频解析器
@@ -20,54 +25,90 @@ type AudioParser struct {
 	referer    string
 }
 
-// NewAudioParser 创建音频解析器
-func NewAudioParser(userAgent, referer, cacheDir string) *AudioParser {
+// NewAudioParser 创建音频解析器，转换输出保存到store
+func NewAudioParser(userAgent, referer, cacheDir string, store storage.Storage) *AudioParser {
 	return &AudioParser{
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 		wbiManager: NewWBIManager(userAgent, referer),
-		downloader: audio.NewDownloader(cacheDir, userAgent, referer),
+		downloader: audio.NewDownloader(cacheDir, store, userAgent, referer),
 		userAgent:  userAgent,
 		referer:    referer,
 	}
 }
 
-// ParseAudio 解析音频资源
-func (p *AudioParser) ParseAudio(bvid string, quality int) (*models.AudioInfo, error) {
+// AccountTier 解析时使用的账号等级，写入缓存键以区分不同账号可获取的音频流
+// 目前不支持登录，始终以匿名身份访问
+func (p *AudioParser) AccountTier() string {
+	return models.AccountAnonymous
+}
+
+// ParseAudio 解析音频资源，opts为调用方指定的转换选项 (片段截取等)
+func (p *AudioParser) ParseAudio(bvid string, quality int, opts audio.ConvertOptions) (*models.AudioInfo, error) {
 	// 1. 获取视频信息
 	videoInfo, err := p.getVideoInfo(bvid)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get video info: %w", err)
 	}
+	cid := videoInfo.Data.CID
 
 	// 2. 获取播放地址
-	playURL, err := p.getPlayURL(videoInfo.Data.CID, bvid, quality)
+	playURL, err := p.getPlayURL(cid, bvid, quality)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get play URL: %w", err)
 	}
 
 	// 3. 解析DASH音频
-	dashInfo, err := p.extractAudioFromDASH(playURL)
+	dashInfo, codec, err := p.extractAudioFromDASH(playURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract audio from DASH: %w", err)
 	}
 
-	// 4. 下载并转换音频
+	// 4. 获取播放器信息 (章节、字幕)，失败不影响音频解析
+	player, err := p.getPlayerInfo(bvid, cid)
+	if err != nil {
+		logrus.Warnf("Failed to get player info for %s: %v", bvid, err)
+	} else {
+		opts.Chapters = chaptersFromPlayer(player)
+	}
+
+	// 5. 下载并转换音频
 	audioInfo, err := p.downloader.DownloadAndConvert(
-		bvid,
-		quality,
+		CIDKey(cid),
+		dashInfo.Quality,     // 实际选中的音频流
+		codec,                // 音频流编码
 		dashInfo.OriginalURL, // 使用原始B站URL
 		dashInfo.Bitrate,
 		dashInfo.Duration,
+		opts,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download and convert audio: %w", err)
 	}
 
+	audioInfo.CID = cid
+	audioInfo.Title = videoInfo.Data.Title
+	audioInfo.Artist = videoInfo.Data.Owner.Name
+	audioInfo.Cover = videoInfo.Data.Pic
+	audioInfo.OriginalURLExpires = originalURLExpires(audioInfo.OriginalURL)
+
+	// 6. 转换CC字幕歌词 (失败不影响音频解析)
+	if player != nil {
+		baseName := strings.TrimSuffix(audioInfo.FileName, filepath.Ext(audioInfo.FileName))
+		if lyrics := p.lyricsFromPlayer(player, baseName, opts.Clip); len(lyrics) > 0 {
+			audioInfo.Lyrics = lyrics
+		}
+	}
+
 	return audioInfo, nil
 }
 
+// CIDKey 视频分P的音频源标识，同一分P经BV号、番剧等不同入口解析时共用输出文件
+func CIDKey(cid int64) string {
+	return "c" + strconv.FormatInt(cid, 10)
+}
+
 // getVideoInfo 获取视频信息
 func (p *AudioParser) getVideoInfo(bvid string) (*VideoInfoResponse, error) {
 	params := map[string]string{
@@ -164,10 +205,10 @@ func (p *AudioParser) getPlayURL(cid int64, bvid string, quality int) (*PlayURLR
 	return &playURL, nil
 }
 
-// extractAudioFromDASH 从DASH中提取音频信息
-func (p *AudioParser) extractAudioFromDASH(playURL *PlayURLResponse) (*models.AudioInfo, error) {
+// extractAudioFromDASH 从DASH中提取音频信息，同时返回选中音频流的编码 (DASH codecs)
+func (p *AudioParser) extractAudioFromDASH(playURL *PlayURLResponse) (*models.AudioInfo, string, error) {
 	if playURL.Data.Dash == nil || len(playURL.Data.Dash.Audio) == 0 {
-		return nil, fmt.Errorf("no audio streams found")
+		return nil, "", fmt.Errorf("no audio streams found")
 	}
 
 	// 选择最高质量的音频流
@@ -191,7 +232,7 @@ func (p *AudioParser) extractAudioFromDASH(playURL *PlayURLResponse) (*models.Au
 	}
 
 	if bestAudio == nil {
-		return nil, fmt.Errorf("no suitable audio stream found")
+		return nil, "", fmt.Errorf("no suitable audio stream found")
 	}
 
 	// 计算比特率 (从带宽估算)
@@ -208,5 +249,33 @@ func (p *AudioParser) extractAudioFromDASH(playURL *PlayURLResponse) (*models.Au
 		FileName:    "", // 将在下载后设置
 	}
 
-	return audioInfo, nil
+	return audioInfo, bestAudio.Codecs, nil
+}
+
+// getJSON 请求B站接口并解析JSON响应
+func (p *AudioParser) getJSON(apiURL string, v interface{}) error {
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", p.userAgent)
+	req.Header.Set("Referer", p.referer)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get response: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,235 @@
+package bilibili
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/audio"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PGC接口错误
+var (
+	// ErrRegionRestricted 所在地区不可观看
+	ErrRegionRestricted = errors.New("content is not available in this region")
+	// ErrPermissionDenied 需要大会员或付费才能观看
+	ErrPermissionDenied = errors.New("content requires membership or purchase")
+)
+
+// PGC接口返回的错误码
+const (
+	pgcCodeRestricted = -10403 // 地区限制或权限不足，需结合message区分
+	pgcCodeNotFound   = -404
+)
+
+var (
+	pgcEpPattern = regexp.MustCompile(`(?i)\bep(\d+)`)
+	pgcSsPattern = regexp.MustCompile(`(?i)\bss(\d+)`)
+)
+
+// ParsePGCID 从ep/ss号或番剧链接中解析单集ID或剧集ID，两者只返回其一
+// 支持: ep123456 / ss12345 / https://www.bilibili.com/bangumi/play/ep123456
+func ParsePGCID(input string) (epID, seasonID int64, err error) {
+	input = strings.TrimSpace(input)
+
+	if m := pgcEpPattern.FindStringSubmatch(input); m != nil {
+		if id, err := strconv.ParseInt(m[1], 10, 64); err == nil && id > 0 {
+			return id, 0, nil
+		}
+	}
+
+	if m := pgcSsPattern.FindStringSubmatch(input); m != nil {
+		if id, err := strconv.ParseInt(m[1], 10, 64); err == nil && id > 0 {
+			return 0, id, nil
+		}
+	}
+
+	return 0, 0, fmt.Errorf("invalid ep/ss id or url: %s", input)
+}
+
+// EpisodeKey 番剧在缓存中使用的标识，ss号表示该剧集的第一集
+func EpisodeKey(epID, seasonID int64) string {
+	if epID > 0 {
+		return "ep" + strconv.FormatInt(epID, 10)
+	}
+	return "ss" + strconv.FormatInt(seasonID, 10)
+}
+
+// ParseEpisode 解析番剧/影视单集音频，seasonID非0时解析该剧集的第一集
+func (p *AudioParser) ParseEpisode(epID, seasonID int64, quality int, opts audio.ConvertOptions) (*models.AudioInfo, error) {
+	// 1. 获取剧集信息，将ep/ss映射到CID
+	season, episode, err := p.findEpisode(epID, seasonID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 获取播放地址
+	playURL, err := p.getPGCPlayURL(episode.ID, episode.CID, quality)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get play URL: %w", err)
+	}
+
+	// 3. 解析DASH音频
+	dashInfo, codec, err := p.extractAudioFromDASH(&PlayURLResponse{Data: playURL.PlayURLData})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract audio from DASH: %w", err)
+	}
+
+	// 4. 下载并转换音频
+	audioInfo, err := p.downloader.DownloadAndConvert(
+		CIDKey(episode.CID),
+		dashInfo.Quality,
+		codec,
+		dashInfo.OriginalURL,
+		dashInfo.Bitrate,
+		dashInfo.Duration,
+		opts,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download and convert audio: %w", err)
+	}
+
+	audioInfo.CID = episode.CID
+	audioInfo.Title = episodeTitle(season.Result.Title, episode)
+	audioInfo.Cover = episode.Cover
+	audioInfo.OriginalURLExpires = originalURLExpires(audioInfo.OriginalURL)
+
+	return audioInfo, nil
+}
+
+// findEpisode 获取剧集信息并找到对应单集，seasonID非0时返回该剧集的第一集
+func (p *AudioParser) findEpisode(epID, seasonID int64) (*PGCSeasonResponse, PGCEpisode, error) {
+	season, err := p.getPGCSeason(epID, seasonID)
+	if err != nil {
+		return nil, PGCEpisode{}, fmt.Errorf("failed to get season info: %w", err)
+	}
+
+	if len(season.Result.Episodes) == 0 {
+		return nil, PGCEpisode{}, fmt.Errorf("season %d has no episodes", season.Result.SeasonID)
+	}
+
+	if epID == 0 {
+		return season, season.Result.Episodes[0], nil
+	}
+	for _, ep := range season.Result.Episodes {
+		if ep.ID == epID {
+			return season, ep, nil
+		}
+	}
+	return nil, PGCEpisode{}, fmt.Errorf("episode %d not found in season %d", epID, season.Result.SeasonID)
+}
+
+// ResolveBangumi 获取番剧/影视剧集的单集列表
+func (p *AudioParser) ResolveBangumi(epID, seasonID int64) (*models.Playlist, error) {
+	season, err := p.getPGCSeason(epID, seasonID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get season info: %w", err)
+	}
+
+	playlist := &models.Playlist{
+		Type:        models.PlaylistTypeBangumi,
+		ID:          season.Result.SeasonID,
+		Title:       season.Result.Title,
+		Cover:       season.Result.Cover,
+		Description: season.Result.Evaluate,
+		Tracks:      []models.Track{},
+	}
+
+	for _, ep := range season.Result.Episodes {
+		playlist.Tracks = append(playlist.Tracks, models.Track{
+			Index:    len(playlist.Tracks) + 1,
+			BVID:     ep.BVID,
+			AID:      ep.AID,
+			CID:      ep.CID,
+			EpID:     ep.ID,
+			Title:    episodeTitle("", ep),
+			Cover:    ep.Cover,
+			Duration: ep.Duration / 1000,
+		})
+	}
+	playlist.Total = len(playlist.Tracks)
+
+	return playlist, nil
+}
+
+// getPGCSeason 获取剧集信息
+func (p *AudioParser) getPGCSeason(epID, seasonID int64) (*PGCSeasonResponse, error) {
+	params := url.Values{}
+	switch {
+	case epID > 0:
+		params.Set("ep_id", strconv.FormatInt(epID, 10))
+	case seasonID > 0:
+		params.Set("season_id", strconv.FormatInt(seasonID, 10))
+	default:
+		return nil, fmt.Errorf("ep_id or season_id is required")
+	}
+
+	var resp PGCSeasonResponse
+	if err := p.getJSON("https://api.bilibili.com/pgc/view/web/season?"+params.Encode(), &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Code != 0 || resp.Result == nil {
+		return nil, pgcError("season", resp.Code, resp.Message)
+	}
+
+	return &resp, nil
+}
+
+// getPGCPlayURL 获取PGC播放地址
+func (p *AudioParser) getPGCPlayURL(epID, cid int64, quality int) (*PGCPlayURLData, error) {
+	params := url.Values{}
+	params.Set("ep_id", strconv.FormatInt(epID, 10))
+	params.Set("cid", strconv.FormatInt(cid, 10))
+	params.Set("fnval", "4048") // 获取所有DASH格式
+	params.Set("fnver", "0")
+	params.Set("fourk", "1")
+
+	if quality > 0 {
+		params.Set("qn", strconv.Itoa(quality))
+	}
+
+	var resp PGCPlayURLResponse
+	if err := p.getJSON("https://api.bilibili.com/pgc/player/web/playurl?"+params.Encode(), &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Code != 0 || resp.Result == nil {
+		return nil, pgcError("playurl", resp.Code, resp.Message)
+	}
+
+	// 仅返回试看片段时视为无权限
+	if resp.Result.IsPreview == 1 {
+		return nil, fmt.Errorf("%w: only preview is available", ErrPermissionDenied)
+	}
+
+	return resp.Result, nil
+}
+
+// pgcError 将PGC接口错误码转换为可区分的错误
+func pgcError(api string, code int, message string) error {
+	if code == pgcCodeRestricted {
+		if strings.Contains(message, "地区") {
+			return fmt.Errorf("%w: code=%d, message=%s", ErrRegionRestricted, code, message)
+		}
+		return fmt.Errorf("%w: code=%d, message=%s", ErrPermissionDenied, code, message)
+	}
+
+	if code == pgcCodeNotFound {
+		return fmt.Errorf("pgc %s not found: code=%d, message=%s", api, code, message)
+	}
+
+	return fmt.Errorf("pgc %s API returned error: code=%d, message=%s", api, code, message)
+}
+
+// episodeTitle 拼接单集标题
+func episodeTitle(seasonTitle string, ep PGCEpisode) string {
+	title := strings.TrimSpace(seasonTitle + " " + ep.Title)
+	if ep.LongTitle != "" {
+		title += " " + ep.LongTitle
+	}
+	return title
+}
@@ -0,0 +1,100 @@
+package bilibili
+
+import (
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
+)
+
+// 以下Refresh系列方法只重新获取元数据与原始播放链接，不下载也不转码，
+// 返回的AudioInfo只包含标题、作者、封面及原始链接等字段，用于刷新缓存条目
+
+// RefreshAudio 重新获取视频的元数据与原始播放链接
+func (p *AudioParser) RefreshAudio(bvid string, quality int) (*models.AudioInfo, error) {
+	videoInfo, err := p.getVideoInfo(bvid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video info: %w", err)
+	}
+
+	playURL, err := p.getPlayURL(videoInfo.Data.CID, bvid, quality)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get play URL: %w", err)
+	}
+
+	dashInfo, _, err := p.extractAudioFromDASH(playURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract audio from DASH: %w", err)
+	}
+
+	return &models.AudioInfo{
+		OriginalURL:        dashInfo.OriginalURL,
+		OriginalURLExpires: originalURLExpires(dashInfo.OriginalURL),
+		Title:              videoInfo.Data.Title,
+		Artist:             videoInfo.Data.Owner.Name,
+		Cover:              videoInfo.Data.Pic,
+	}, nil
+}
+
+// RefreshSong 重新获取音频区歌曲的元数据与原始播放链接
+func (p *AudioParser) RefreshSong(sid int64, quality int) (*models.AudioInfo, error) {
+	if _, ok := songQualityBitrate[quality]; !ok {
+		quality = SongQuality320K
+	}
+
+	info, err := p.getSongInfo(sid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get song info: %w", err)
+	}
+
+	streamURL, err := p.getSongURL(sid, quality)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get song URL: %w", err)
+	}
+
+	artist := info.Author
+	if artist == "" {
+		artist = info.Uname
+	}
+	return &models.AudioInfo{
+		OriginalURL:        streamURL,
+		OriginalURLExpires: originalURLExpires(streamURL),
+		Title:              info.Title,
+		Artist:             artist,
+		Cover:              info.Cover,
+		LyricURL:           info.Lyric,
+	}, nil
+}
+
+// RefreshEpisode 重新获取番剧/影视单集的元数据与原始播放链接
+func (p *AudioParser) RefreshEpisode(epID, seasonID int64, quality int) (*models.AudioInfo, error) {
+	season, episode, err := p.findEpisode(epID, seasonID)
+	if err != nil {
+		return nil, err
+	}
+
+	playURL, err := p.getPGCPlayURL(episode.ID, episode.CID, quality)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get play URL: %w", err)
+	}
+
+	dashInfo, _, err := p.extractAudioFromDASH(&PlayURLResponse{Data: playURL.PlayURLData})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract audio from DASH: %w", err)
+	}
+
+	return &models.AudioInfo{
+		OriginalURL:        dashInfo.OriginalURL,
+		OriginalURLExpires: originalURLExpires(dashInfo.OriginalURL),
+		Title:              episodeTitle(season.Result.Title, episode),
+		Cover:              episode.Cover,
+	}, nil
+}
+
+// originalURLExpires 原始播放链接的过期时间 (Unix秒)，未知时为0
+func originalURLExpires(rawURL string) int64 {
+	deadline := utils.URLDeadline(rawURL)
+	if deadline.IsZero() {
+		return 0
+	}
+	return deadline.Unix()
+}
@@ -0,0 +1,115 @@
+package bilibili
+
+import (
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/audio"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// getPlayerInfo 获取播放器信息 (字幕列表、分段章节等)
+func (p *AudioParser) getPlayerInfo(bvid string, cid int64) (*PlayerInfoResponse, error) {
+	params := map[string]string{
+		"bvid": bvid,
+		"cid":  strconv.FormatInt(cid, 10),
+	}
+
+	query, err := p.wbiManager.SignParams(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign params: %w", err)
+	}
+
+	var player PlayerInfoResponse
+	if err := p.getJSON("https://api.bilibili.com/x/player/wbi/v2?"+query, &player); err != nil {
+		return nil, fmt.Errorf("failed to get player info: %w", err)
+	}
+
+	if player.Code != 0 {
+		return nil, fmt.Errorf("player API returned error: code=%d, message=%s", player.Code, player.Message)
+	}
+
+	return &player, nil
+}
+
+// lyricsFromPlayer 下载播放器信息中的字幕并转换保存为 <baseName>.<lan>.lrc/.vtt，clip非空时按截取范围平移时间轴
+// 单个字幕下载或保存失败时跳过该字幕
+func (p *AudioParser) lyricsFromPlayer(player *PlayerInfoResponse, baseName string, clip *audio.ClipRange) []models.LyricTrack {
+	tracks := []models.LyricTrack{}
+	for _, sub := range player.Data.Subtitle.Subtitles {
+		if sub.SubtitleURL == "" {
+			continue
+		}
+
+		lines, err := p.getSubtitleLines(sub.SubtitleURL)
+		if err != nil {
+			logrus.Warnf("Failed to get subtitle %s: %v", sub.Lan, err)
+			continue
+		}
+		if clip != nil {
+			lines = clip.ClipLyrics(lines)
+		}
+
+		track := models.LyricTrack{
+			Lang:    sub.Lan,
+			LangDoc: sub.LanDoc,
+			AI:      strings.HasPrefix(sub.Lan, "ai-"),
+			LRCFile: baseName + "." + sub.Lan + ".lrc",
+			VTTFile: baseName + "." + sub.Lan + ".vtt",
+		}
+
+		if track.LRCURL, err = p.downloader.SaveSidecar(track.LRCFile, []byte(audio.ToLRC(lines))); err != nil {
+			logrus.Warnf("Failed to save subtitle %s: %v", sub.Lan, err)
+			continue
+		}
+		if track.VTTURL, err = p.downloader.SaveSidecar(track.VTTFile, []byte(audio.ToWebVTT(lines))); err != nil {
+			logrus.Warnf("Failed to save subtitle %s: %v", sub.Lan, err)
+			continue
+		}
+
+		tracks = append(tracks, track)
+	}
+
+	return tracks
+}
+
+// getSubtitleLines 下载B站JSON字幕并转换为歌词行
+func (p *AudioParser) getSubtitleLines(subtitleURL string) ([]audio.LyricLine, error) {
+	if strings.HasPrefix(subtitleURL, "//") {
+		subtitleURL = "https:" + subtitleURL
+	}
+
+	var body SubtitleBody
+	if err := p.getJSON(subtitleURL, &body); err != nil {
+		return nil, err
+	}
+
+	lines := make([]audio.LyricLine, 0, len(body.Body))
+	for _, item := range body.Body {
+		lines = append(lines, audio.LyricLine{
+			From:    item.From,
+			To:      item.To,
+			Content: item.Content,
+		})
+	}
+
+	return lines, nil
+}
+
+// chaptersFromPlayer 将分段章节 (view_points) 转换为音频章节
+func chaptersFromPlayer(player *PlayerInfoResponse) []models.Chapter {
+	var chapters []models.Chapter
+	for _, vp := range player.Data.ViewPoints {
+		if strings.TrimSpace(vp.Content) == "" {
+			continue
+		}
+		chapters = append(chapters, models.Chapter{
+			Title: vp.Content,
+			Start: vp.From,
+			End:   vp.To,
+		})
+	}
+	return chapters
+}
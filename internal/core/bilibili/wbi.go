@@ -10,18 +10,27 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
+// wbiRetryDelay nav接口失败后再次请求前的等待时间，期间沿用旧密钥
+const wbiRetryDelay = time.Minute
+
 // WBI签名管理器
 type WBIManager struct {
-	imgKey     string
-	subKey     string
-	mixinKey   string
-	client     *http.Client
-	lastUpdate time.Time
-	userAgent  string
-	referer    string
+	imgKey      string
+	subKey      string
+	mixinKey    string
+	client      *http.Client
+	lastUpdate  time.Time
+	lastFailure time.Time // 最近一次更新失败的时间
+	userAgent   string
+	referer     string
+
+	mu sync.Mutex // 保护密钥与更新时间，更新期间一直持有，并发请求只触发一次nav请求
 }
 
 // NewWBIManager 创建新的WBI管理器
@@ -45,11 +54,38 @@ var mixinKeyEncTab = []int{
 
 // UpdateWBIKeys 更新WBI密钥
 func (w *WBIManager) UpdateWBIKeys() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.updateLocked()
+}
+
+// updateLocked 密钥超过1小时时更新，失败后wbiRetryDelay内不再请求，已有密钥时沿用旧密钥
+// 调用方需持有w.mu
+func (w *WBIManager) updateLocked() error {
 	// 如果密钥更新时间在1小时内，不需要重新获取
 	if time.Since(w.lastUpdate) < time.Hour {
 		return nil
 	}
+	if time.Since(w.lastFailure) < wbiRetryDelay {
+		if w.mixinKey != "" {
+			return nil
+		}
+		return fmt.Errorf("nav API failed recently, retrying after %s", wbiRetryDelay)
+	}
 
+	if err := w.fetchKeys(); err != nil {
+		w.lastFailure = time.Now()
+		if w.mixinKey != "" {
+			logrus.Warnf("Failed to update WBI keys, keeping previous keys: %v", err)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// fetchKeys 从nav接口获取WBI密钥，调用方需持有w.mu
+func (w *WBIManager) fetchKeys() error {
 	req, err := http.NewRequest("GET", "https://api.bilibili.com/x/web-interface/nav", nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
@@ -116,7 +152,11 @@ func (w *WBIManager) generateMixinKey() string {
 // SignParams 对参数进行WBI签名
 func (w *WBIManager) SignParams(params map[string]string) (string, error) {
 	// 确保密钥是最新的
-	if err := w.UpdateWBIKeys(); err != nil {
+	w.mu.Lock()
+	err := w.updateLocked()
+	mixinKey := w.mixinKey
+	w.mu.Unlock()
+	if err != nil {
 		return "", fmt.Errorf("failed to update WBI keys: %w", err)
 	}
 
@@ -150,7 +190,7 @@ func (w *WBIManager) SignParams(params map[string]string) (string, error) {
 	query := strings.Join(queryParts, "&")
 
 	// 计算签名
-	toSign := query + w.mixinKey
+	toSign := query + mixinKey
 	hash := md5.Sum([]byte(toSign))
 	wRid := fmt.Sprintf("%x", hash)
 
@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ListFilter 列出缓存记录的筛选与排序条件，零值表示不筛选
+type ListFilter struct {
+	BVID    string // 资源标识 (BV号、au号等)
+	Quality *int   // 音质
+	Pinned  *bool  // 是否固定
+	Expired *bool  // 是否已过期 (固定的条目视为未过期)
+	Sort    string // recent (最近访问，默认) / hits (命中次数) / created (创建时间) / expires (过期时间)
+}
+
+// listOrders 排序方式对应的ORDER BY子句
+var listOrders = map[string]string{
+	"recent":  "last_access_at DESC, created_at DESC",
+	"hits":    "hit_count DESC, last_access_at DESC",
+	"created": "created_at DESC",
+	"expires": "expires_at ASC",
+}
+
+// ValidSort 是否为支持的排序方式
+func ValidSort(sort string) bool {
+	_, ok := listOrders[sort]
+	return sort == "" || ok
+}
+
+// apply 将筛选条件加入查询
+func (f ListFilter) apply(query *gorm.DB, now time.Time) *gorm.DB {
+	if f.BVID != "" {
+		query = query.Where(&models.CacheRecord{BVID: f.BVID})
+	}
+	if f.Quality != nil {
+		query = query.Where("quality = ?", *f.Quality)
+	}
+	if f.Pinned != nil {
+		query = query.Where("pinned = ?", *f.Pinned)
+	}
+	if f.Expired != nil {
+		if *f.Expired {
+			query = query.Where("expires_at <= ? AND pinned = ?", now, false)
+		} else {
+			query = query.Where("(expires_at > ? OR pinned = ?)", now, true)
+		}
+	}
+	return query
+}
+
+// List 按筛选条件分页列出缓存记录 (含访问时间与命中次数)，同时返回符合条件的总数
+func (m *Manager) List(filter ListFilter, offset, limit int) ([]models.CacheRecord, int64, error) {
+	// 先写回内存层命中的访问记录，保证排序准确
+	m.memory.flushAccess(m.db)
+
+	now := time.Now()
+	var total int64
+	if err := filter.apply(m.db.Model(&models.CacheRecord{}), now).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count cache records: %w", err)
+	}
+
+	order, ok := listOrders[filter.Sort]
+	if !ok {
+		order = listOrders["recent"]
+	}
+	var records []models.CacheRecord
+	if err := filter.apply(m.db, now).Order(order).Offset(offset).Limit(limit).Find(&records).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list cache records: %w", err)
+	}
+	return records, total, nil
+}
+
+// EntryFile 缓存条目占用的一个文件
+type EntryFile struct {
+	Name     string `json:"name"`                // 相对于存储根目录的名称，JSON条目为文件名
+	Size     int64  `json:"size"`                // 字节数，HLS目录为其中全部文件之和
+	Exists   bool   `json:"exists"`              // 文件是否存在
+	RefCount int    `json:"ref_count,omitempty"` // 引用该音频文件的条目数，仅音频文件有效
+}
+
+// Entry 缓存条目详情
+type Entry struct {
+	Record    models.CacheRecord `json:"record"`
+	Spec      *Key               `json:"spec,omitempty"`  // 结构化缓存键，旧版本条目为空
+	Title     string             `json:"title,omitempty"` // 缓存的标题
+	Files     []EntryFile        `json:"files"`           // JSON条目、音频及其配套文件
+	TotalSize int64              `json:"total_size"`      // 全部文件的字节数 (共用的音频文件也计入)
+}
+
+// Entry 返回缓存条目的详情，包括JSON条目、音频及配套文件的大小，不存在时返回nil
+func (m *Manager) Entry(key string) (*Entry, error) {
+	var record models.CacheRecord
+	result := m.db.Where("cache_key = ?", key).Limit(1).Find(&record)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to find cache record: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	entry := &Entry{Record: record}
+	jsonFile := EntryFile{Name: filepath.Base(record.FilePath)}
+	if info, err := os.Stat(record.FilePath); err == nil {
+		jsonFile.Size = info.Size()
+		jsonFile.Exists = true
+	}
+	entry.Files = append(entry.Files, jsonFile)
+
+	if item := m.readItem(record.FilePath); item != nil {
+		entry.Spec = item.Spec
+		if item.Data != nil {
+			entry.Title = item.Data.Title
+			for _, name := range item.Data.Files() {
+				file := EntryFile{Name: name}
+				if info, err := m.store.Stat(name); err == nil {
+					file.Size = info.Size
+					file.Exists = true
+				}
+				if name == item.Data.FileName {
+					var audioFile models.AudioFile
+					if m.db.Where("file_name = ?", name).Limit(1).Find(&audioFile).RowsAffected > 0 {
+						file.RefCount = audioFile.RefCount
+					}
+				}
+				entry.Files = append(entry.Files, file)
+			}
+		}
+	}
+
+	for _, file := range entry.Files {
+		entry.TotalSize += file.Size
+	}
+	return entry, nil
+}
+
+// PurgeOptions 清除条件，BVID、Key、OlderThan可组合，All为true时忽略其他条件
+type PurgeOptions struct {
+	BVID          string        // 资源标识，匹配该资源的全部音质与变体
+	Key           string        // 缓存键，匹配单个条目
+	OlderThan     time.Duration // 创建时间早于 当前时间-OlderThan 的条目
+	All           bool          // 全部条目
+	IncludePinned bool          // 同时清除固定的条目，默认跳过
+}
+
+// PurgeReport 清除结果
+type PurgeReport struct {
+	Removed    int   `json:"removed"`     // 删除的条目数
+	Skipped    int   `json:"skipped"`     // 因固定而跳过的条目数
+	FreedBytes int64 `json:"freed_bytes"` // 释放的字节数 (共用的音频文件在最后一个引用删除时才释放)
+}
+
+// Purge 按条件删除缓存条目及其文件引用，不设置任何条件时返回错误
+func (m *Manager) Purge(opts PurgeOptions) (*PurgeReport, error) {
+	query := m.db.Model(&models.CacheRecord{})
+	if !opts.All {
+		if opts.BVID == "" && opts.Key == "" && opts.OlderThan <= 0 {
+			return nil, fmt.Errorf("bvid, key, older_than or all is required")
+		}
+		if opts.BVID != "" {
+			query = query.Where(&models.CacheRecord{BVID: opts.BVID})
+		}
+		if opts.Key != "" {
+			query = query.Where("cache_key = ?", opts.Key)
+		}
+		if opts.OlderThan > 0 {
+			query = query.Where("created_at < ?", time.Now().Add(-opts.OlderThan))
+		}
+	}
+
+	var records []models.CacheRecord
+	if err := query.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to find cache records: %w", err)
+	}
+
+	report := &PurgeReport{}
+	for _, record := range records {
+		if record.Pinned && !opts.IncludePinned {
+			report.Skipped++
+			continue
+		}
+		freed, _ := m.evict(record)
+		report.FreedBytes += freed
+		report.Removed++
+	}
+
+	if report.Removed > 0 {
+		fmt.Printf("Purged %d cache entries, freed %d bytes\n", report.Removed, report.FreedBytes)
+	}
+	return report, nil
+}
@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// evictionBatch 每次从数据库取出的候选条目数
+const evictionBatch = 50
+
+// evictionDelay 写入缓存后延迟执行淘汰，期间的多次写入只触发一次
+const evictionDelay = 10 * time.Second
+
+// scheduleEviction 在evictionDelay后执行一次淘汰，已有待执行的淘汰时不重复安排
+func (m *Manager) scheduleEviction() {
+	if !atomic.CompareAndSwapInt32(&m.evictionScheduled, 0, 1) {
+		return
+	}
+	time.AfterFunc(evictionDelay, func() {
+		atomic.StoreInt32(&m.evictionScheduled, 0)
+		if err := m.EnforceLimits(); err != nil {
+			fmt.Printf("Cache eviction error: %v\n", err)
+		}
+	})
+}
+
+// EnforceLimits 缓存超出容量或文件数限制时，按淘汰策略 (lru/lfu) 删除条目直到满足限制
+// 占用空间按被引用的音频文件计算，孤儿文件由对账清理，不计入限制
+// 共用的音频文件在最后一个引用它的条目被淘汰后才会删除
+func (m *Manager) EnforceLimits() error {
+	if m.maxSize <= 0 && m.maxFiles <= 0 {
+		return nil
+	}
+
+	// 同一时间只执行一次淘汰
+	if !atomic.CompareAndSwapInt32(&m.evicting, 0, 1) {
+		return nil
+	}
+	defer atomic.StoreInt32(&m.evicting, 0)
+
+	// 先写回内存层命中的访问记录，lru/lfu依赖访问时间与命中次数
+	m.memory.flushAccess(m.db)
+
+	order := "last_access_at ASC, created_at ASC"
+	if m.eviction == "lfu" {
+		order = "hit_count ASC, last_access_at ASC"
+	}
+
+	evicted := 0
+	var freedTotal int64
+	var attempted []uint // 已尝试淘汰的记录，删除失败时不再重复选中
+	for {
+		// 每批开始时按引用计数表重新统计
+		size, files, err := m.referencedUsage()
+		if err != nil {
+			return err
+		}
+		overLimit := func() bool {
+			return (m.maxSize > 0 && size > m.maxSize) || (m.maxFiles > 0 && files > int64(m.maxFiles))
+		}
+		if !overLimit() {
+			break
+		}
+
+		var records []models.CacheRecord
+		// 固定的条目不参与淘汰，全部为固定条目时即使仍超出限制也停止
+		query := m.db.Where("pinned = ?", false)
+		if len(attempted) > 0 {
+			query = query.Where("id NOT IN ?", attempted)
+		}
+		if err := query.Order(order).Limit(evictionBatch).Find(&records).Error; err != nil {
+			return fmt.Errorf("failed to find eviction candidates: %w", err)
+		}
+		if len(records) == 0 {
+			break
+		}
+
+		var freedBatch int64
+		for _, record := range records {
+			if !overLimit() {
+				break
+			}
+
+			attempted = append(attempted, record.ID)
+			freed, removedFile := m.evict(record)
+			size -= freed
+			freedBatch += freed
+			if removedFile {
+				files--
+			}
+			evicted++
+		}
+		freedTotal += freedBatch
+
+		// 整批没有释放任何空间 (如删除持续失败) 时停止，避免空转
+		if freedBatch == 0 {
+			break
+		}
+	}
+
+	if evicted > 0 {
+		fmt.Printf("Evicted %d cache entries (%s), freed %d bytes\n", evicted, m.eviction, freedTotal)
+	}
+
+	return nil
+}
+
+// evict 删除一个缓存条目并释放其文件引用，返回释放的字节数及音频文件是否被删除
+func (m *Manager) evict(record models.CacheRecord) (int64, bool) {
+	m.reconcileMu.RLock()
+	defer m.reconcileMu.RUnlock()
+
+	var freed int64
+	item := m.readItem(record.FilePath)
+
+	freed += pathSize(record.FilePath)
+	if err := os.Remove(record.FilePath); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Warning: failed to remove cache file %s: %v\n", record.FilePath, err)
+	}
+
+	removedFile := false
+	if item != nil {
+		released := m.releaseAudioFiles(item.Data)
+		freed += released
+		removedFile = released > 0
+	}
+
+	if err := m.db.Delete(&record).Error; err != nil {
+		fmt.Printf("Warning: failed to delete cache record %d: %v\n", record.ID, err)
+	}
+	m.memory.remove(record.CacheKey)
+
+	return freed, removedFile
+}
+
+// referencedUsage 被缓存条目引用的音频文件的总大小与数量
+func (m *Manager) referencedUsage() (int64, int64, error) {
+	var usage struct {
+		Size  int64
+		Files int64
+	}
+	err := m.db.Model(&models.AudioFile{}).
+		Select("COALESCE(SUM(size), 0) AS size, COUNT(*) AS files").
+		Scan(&usage).Error
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to sum audio file sizes: %w", err)
+	}
+	return usage.Size, usage.Files, nil
+}
@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"crypto/md5"
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// KeyVersion 缓存键格式版本，格式变化时递增，并在MigrateKeys中迁移旧版本条目
+// 版本1 (旧版本) 为 md5(资源标识_音质[_变体])，不区分分P与账号
+const KeyVersion = 2
+
+// Key 结构化缓存键，任何影响输出文件的请求参数都需要体现在键中
+// CID与Stream是解析结果 (分P的cid与实际选中的音频流)，由资源标识、分P、音质与账号等级决定，
+// 查找时尚未解析因而不参与Hash，写入缓存时由Set补全，记录条目实际对应的音频
+type Key struct {
+	Source  string `json:"source"`            // 资源标识: BV号、au<id>、ep<id>、ss<id>
+	Page    int    `json:"page,omitempty"`    // 分P序号，0表示默认分P
+	Quality int    `json:"quality"`           // 请求的音质 (音频流编号)，音频区-1表示默认音质
+	Account string `json:"account"`           // 账号等级，决定可获取的音频流 (如大会员专享音质)
+	Profile string `json:"profile,omitempty"` // 转码配置名称及内容摘要
+	Clip    string `json:"clip,omitempty"`    // 截取范围及淡入淡出
+	Options string `json:"options,omitempty"` // 其他输出选项 (响度标准化、ReplayGain、HLS、波形)，按固定顺序以";"连接
+
+	CID    int64 `json:"cid,omitempty"`    // 分P的cid，音频区为0
+	Stream int   `json:"stream,omitempty"` // 实际选中的音频流编号
+}
+
+// String 规范化表示，包含格式版本
+func (k Key) String() string {
+	return strings.Join([]string{
+		"v" + strconv.Itoa(KeyVersion),
+		"source=" + url.QueryEscape(k.Source),
+		"page=" + strconv.Itoa(k.Page),
+		"quality=" + strconv.Itoa(k.Quality),
+		"account=" + url.QueryEscape(k.Account),
+		"profile=" + url.QueryEscape(k.Profile),
+		"clip=" + url.QueryEscape(k.Clip),
+		"options=" + url.QueryEscape(k.Options),
+	}, "|")
+}
+
+// Hash 缓存条目的键 (规范化表示的md5)，用作JSON条目文件名与数据库记录的cache_key
+func (k Key) Hash() string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(k.String())))
+}
+
+// Variant 输出变体摘要 (profile=...;clip=...;其他选项)，完整音频为空，记录在数据库中便于查看
+func (k Key) Variant() string {
+	var parts []string
+	if k.Profile != "" {
+		parts = append(parts, "profile="+k.Profile)
+	}
+	if k.Clip != "" {
+		parts = append(parts, "clip="+k.Clip)
+	}
+	if k.Options != "" {
+		parts = append(parts, k.Options)
+	}
+	return strings.Join(parts, ";")
+}
+
+// legacyKey 版本1的缓存键
+func legacyKey(source string, quality int, variant string) string {
+	data := source + "_" + strconv.Itoa(quality)
+	if variant != "" {
+		data += "_" + variant
+	}
+	return fmt.Sprintf("%x", md5.Sum([]byte(data)))
+}
+
+// keyFromLegacy 从版本1条目的资源标识、音质与变体还原结构化键
+// 版本1只支持匿名访问和默认分P，变体按Variant的格式拆分
+func keyFromLegacy(source string, quality int, variant string) Key {
+	key := Key{Source: source, Quality: quality, Account: models.AccountAnonymous}
+
+	var options []string
+	for _, part := range strings.Split(variant, ";") {
+		switch {
+		case part == "":
+		case strings.HasPrefix(part, "profile="):
+			key.Profile = strings.TrimPrefix(part, "profile=")
+		case strings.HasPrefix(part, "clip="):
+			key.Clip = strings.TrimPrefix(part, "clip=")
+		default:
+			options = append(options, part)
+		}
+	}
+	key.Options = strings.Join(options, ";")
+	return key
+}
+
+// MigrateKeys 将旧版本缓存键的条目迁移到当前版本：按结构化键重命名JSON条目并更新数据库记录
+// 新键已存在时丢弃旧条目；无法确定资源标识的条目保持原样，过期后正常清理
+// 在启动时、开始处理请求前调用，返回迁移的条目数
+func (m *Manager) MigrateKeys() (int, error) {
+	entries, err := os.ReadDir(m.cacheDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
+	migrated := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !cacheKeyFile.MatchString(entry.Name()) {
+			continue
+		}
+		oldPath := filepath.Join(m.cacheDir, entry.Name())
+		item := m.readItem(oldPath)
+		if item == nil || item.Version >= KeyVersion {
+			continue
+		}
+		oldKey := strings.TrimSuffix(entry.Name(), ".json")
+
+		// 早期条目的JSON中没有资源标识，从数据库记录中读取
+		source, quality, variant := item.BVID, item.Quality, item.Variant
+		var record models.CacheRecord
+		if m.db.Where("cache_key = ?", oldKey).Limit(1).Find(&record).RowsAffected > 0 && source == "" {
+			source, quality, variant = record.BVID, record.Quality, record.Variant
+		}
+		if source == "" {
+			continue
+		}
+
+		spec := keyFromLegacy(source, quality, variant)
+		newKey := spec.Hash()
+		newPath := filepath.Join(m.cacheDir, newKey+".json")
+
+		if _, err := os.Stat(newPath); err == nil {
+			// 迁移前已按新键缓存过，旧条目不再需要
+			os.Remove(oldPath)
+			m.releaseAudioFiles(item.Data)
+			m.db.Where("cache_key = ?", oldKey).Delete(&models.CacheRecord{})
+			continue
+		}
+
+		item.applyKey(newKey, spec)
+		if err := m.writeItem(newPath, item); err != nil {
+			return migrated, err
+		}
+		if err := os.Remove(oldPath); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Warning: failed to remove migrated cache file %s: %v\n", oldPath, err)
+		}
+
+		// 没有记录的条目由对账根据JSON补回
+		m.db.Model(&models.CacheRecord{}).Where("cache_key = ?", oldKey).UpdateColumns(map[string]interface{}{
+			"cache_key":   newKey,
+			"file_path":   newPath,
+			"variant":     spec.Variant(),
+			"page":        spec.Page,
+			"account":     spec.Account,
+			"key_version": KeyVersion,
+		})
+		migrated++
+	}
+
+	if migrated > 0 {
+		m.memory.clear()
+		fmt.Printf("Migrated %d cache entries to key version %d\n", migrated, KeyVersion)
+	}
+	return migrated, nil
+}
@@ -2,58 +2,180 @@ package cache
 
 import (
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/config"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/storage"
 	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
-	"crypto/md5"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// slideThreshold 滑动过期时顺延幅度小于该值则不更新
+const slideThreshold = time.Minute
+
 // Manager 缓存管理器
 type Manager struct {
-	cacheDir string
+	cacheDir string          // 缓存条目JSON所在的本地目录
+	store    storage.Storage // 音频及配套文件的存储后端
 	ttl      config.Duration
 	db       *gorm.DB
+
+	slidingTTL  bool            // 命中时顺延过期时间
+	maxLifetime config.Duration // 顺延的上限，从创建时间算起
+	metadataTTL config.Duration // 元数据与原始链接的刷新间隔
+
+	memory      *memoryCache // 内存层，nil表示不启用
+	memoryStats tierCounters
+	diskStats   tierCounters
+
+	writeMu      sync.Mutex           // 串行化缓存条目JSON的改写
+	reconcileMu  sync.RWMutex         // 对账期间独占，写入、删除缓存条目及改动引用计数时共享
+	revalidateMu sync.Mutex           // 保护revalidating
+	revalidating map[string]time.Time // 正在刷新或revalidateRetry内刷新失败的条目及开始时间
+
+	maxSize  int64  // 缓存目录最大占用空间，0表示不限制
+	maxFiles int    // 最大音频文件数，0表示不限制
+	eviction string // 淘汰策略 lru / lfu
+
+	evicting          int32 // 是否正在执行淘汰
+	evictionScheduled int32 // 是否已安排延迟淘汰
 }
 
 // CacheItem 缓存项
 type CacheItem struct {
 	Key       string            `json:"key"`
+	BVID      string            `json:"bvid,omitempty"`    // 资源标识，用于从JSON恢复数据库记录
+	Quality   int               `json:"quality,omitempty"` // 请求的音质
+	Variant   string            `json:"variant,omitempty"` // 输出变体
+	Version   int               `json:"version,omitempty"` // 缓存键格式版本，旧版本条目为0
+	Spec      *Key              `json:"spec,omitempty"`    // 结构化缓存键
 	Data      *models.AudioInfo `json:"data"`
 	CreatedAt time.Time         `json:"created_at"`
 	ExpiresAt time.Time         `json:"expires_at"`
+
+	RefreshedAt time.Time `json:"refreshed_at"` // 元数据与原始链接的最近刷新时间，零值表示未刷新过
+	Pinned      bool      `json:"pinned,omitempty"` // 固定的条目不会过期，也不会被淘汰，对账时据此恢复数据库记录
 }
 
-// NewManager 创建缓存管理器
-func NewManager(cacheDir string, ttl config.Duration, db *gorm.DB) *Manager {
+// applyKey 设置条目的缓存键及由其派生的字段
+func (item *CacheItem) applyKey(hash string, key Key) {
+	item.Key = hash
+	item.BVID = key.Source
+	item.Quality = key.Quality
+	item.Variant = key.Variant()
+	item.Version = KeyVersion
+	item.Spec = &key
+}
+
+// expired 条目是否已过期，固定的条目永不过期
+func (item *CacheItem) expired(now time.Time) bool {
+	return !item.Pinned && now.After(item.ExpiresAt)
+}
+
+// NewManager 创建缓存管理器，store为音频文件所在的存储后端
+func NewManager(cfg config.CacheConfig, db *gorm.DB, store storage.Storage) *Manager {
 	// 确保缓存目录存在
-	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
 		// 记录错误但不中断程序
 		fmt.Printf("Warning: failed to create cache directory: %v\n", err)
 	}
 
 	return &Manager{
-		cacheDir: cacheDir,
-		ttl:      ttl,
+		cacheDir: cfg.Dir,
+		store:    store,
+		ttl:      cfg.TTL,
 		db:       db,
+
+		slidingTTL:  cfg.SlidingTTL,
+		maxLifetime: cfg.MaxLifetime,
+		metadataTTL: cfg.MetadataTTL,
+
+		memory: newMemoryCache(cfg.MemoryEntries),
+
+		revalidating: make(map[string]time.Time),
+
+		maxSize:  int64(cfg.MaxSize),
+		maxFiles: cfg.MaxFiles,
+		eviction: cfg.Eviction,
 	}
 }
 
 // Get 获取缓存
-func (m *Manager) Get(bvid string, quality int) *models.AudioInfo {
-	key := m.generateKey(bvid, quality)
+func (m *Manager) Get(key Key) *models.AudioInfo {
+	return m.GetFresh(key, nil)
+}
+
+// Has 缓存中是否有未过期的条目，不记录访问 (供预热等内部检查使用)
+func (m *Manager) Has(key Key) bool {
+	var count int64
+	m.db.Model(&models.CacheRecord{}).Where("cache_key = ? AND (expires_at > ? OR pinned = ?)", key.Hash(), time.Now(), true).Count(&count)
+	return count > 0
+}
+
+// lookup 读取缓存条目并记录访问，先查内存层，未命中时读取数据库与JSON并写入内存层
+// 条目不存在、过期或文件缺失时清理并返回nil
+func (m *Manager) lookup(key string) *CacheItem {
+	now := time.Now()
+
+	if m.memory != nil {
+		version := m.memory.current()
+		if item := m.memory.get(key, now); item != nil && !m.fileMissing(item) {
+			m.memoryStats.record(true)
+			if m.slideExpiry(item, now) {
+				m.db.Model(&models.CacheRecord{}).Where("cache_key = ?", key).UpdateColumn("expires_at", item.ExpiresAt)
+				m.memory.put(item, version)
+			}
+			if m.memory.flushDue(now) {
+				go m.memory.flushAccess(m.db)
+			}
+			m.setExpiring(item, now)
+			return item
+		}
+		m.memoryStats.record(false)
+	}
+
+	item := m.load(key, now)
+	m.diskStats.record(item != nil)
+	if item != nil {
+		m.setExpiring(item, now)
+	}
+	return item
+}
+
+// fileMissing 内存层条目的音频文件是否已被删除 (例如被外部清理)，是时使内存层条目失效，由load负责清理记录
+// 对象存储的Stat结果会短暂缓存，不会每次命中都请求存储
+func (m *Manager) fileMissing(item *CacheItem) bool {
+	if item.Data == nil || item.Data.FileName == "" {
+		return false
+	}
+	if _, err := m.store.Stat(item.Data.FileName); !errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	m.memory.remove(item.Key)
+	return true
+}
+
+// load 从数据库与JSON读取缓存条目并记录访问，成功时写入内存层
+func (m *Manager) load(key string, now time.Time) *CacheItem {
+	m.reconcileMu.RLock()
+	defer m.reconcileMu.RUnlock()
+
+	// 读取前获取版本号，读取期间条目被改写或删除时不写入内存层
+	version := m.memory.current()
 
 	// 1. 检查数据库记录
 	var record models.CacheRecord
-	now := time.Now()
 
 	// 使用Find而不是First，避免"record not found"日志
-	result := m.db.Where("cache_key = ? AND expires_at > ?", key, now).Limit(1).Find(&record)
+	result := m.db.Where("cache_key = ? AND (expires_at > ? OR pinned = ?)", key, now, true).Limit(1).Find(&record)
 	if result.Error != nil {
 		// 查询出错
 		return nil
@@ -83,33 +205,45 @@ func (m *Manager) Get(bvid string, quality int) *models.AudioInfo {
 	}
 
 	// 检查是否过期
-	if time.Now().After(item.ExpiresAt) {
+	if item.expired(time.Now()) {
 		// 过期，清理
 		os.Remove(filePath)
-		// 如果有MP3文件也要清理
-		if item.Data != nil && item.Data.FileName != "" {
-			mp3Path := filepath.Join(m.cacheDir, item.Data.FileName)
-			os.Remove(mp3Path)
-		}
+		// 释放对MP3及歌词等文件的引用，无其他引用时删除
+		m.releaseAudioFiles(item.Data)
 		m.db.Delete(&record)
 		return nil
 	}
 
 	// 检查MP3文件是否存在
 	if item.Data != nil && item.Data.FileName != "" {
-		mp3Path := filepath.Join(m.cacheDir, item.Data.FileName)
-		if _, err := os.Stat(mp3Path); err != nil {
+		_, err := m.store.Stat(item.Data.FileName)
+		if errors.Is(err, os.ErrNotExist) {
 			// MP3文件不存在，清理缓存
 			os.Remove(filePath)
+			m.releaseAudioFiles(item.Data)
 			m.db.Delete(&record)
 			return nil
 		}
 	}
 
-	// 更新expiring字段为剩余过期时间
+	// 记录访问时间与命中次数，滑动过期模式下同时顺延过期时间
+	updates := map[string]interface{}{
+		"last_access_at": now,
+		"hit_count":      gorm.Expr("hit_count + 1"),
+	}
+	if m.slideExpiry(&item, now) {
+		updates["expires_at"] = item.ExpiresAt
+	}
+	m.db.Model(&record).UpdateColumns(updates)
+
+	m.memory.put(&item, version)
+	return &item
+}
+
+// setExpiring 更新expiring字段为剩余过期时间
+func (m *Manager) setExpiring(item *CacheItem, now time.Time) {
 	if item.Data != nil {
-		now := time.Now()
-		if m.ttl.IsNever {
+		if m.ttl.IsNever || item.Pinned {
 			item.Data.Expiring = -1 // 永不过期
 		} else {
 			remainingSeconds := int64(item.ExpiresAt.Sub(now).Seconds())
@@ -119,13 +253,76 @@ func (m *Manager) Get(bvid string, quality int) *models.AudioInfo {
 			item.Data.Expiring = remainingSeconds
 		}
 	}
+}
+
+// slideExpiry 滑动过期模式下将条目过期时间顺延为 now+ttl (不超过创建时间+max_lifetime) 并写回JSON
+// 顺延幅度不足slideThreshold时不写入，避免热门条目每次命中都重写文件
+func (m *Manager) slideExpiry(item *CacheItem, now time.Time) bool {
+	if !m.slidingTTL || m.ttl.IsNever || item.Pinned {
+		return false
+	}
+
+	expiresAt := now.Add(m.ttl.Duration)
+	if !m.maxLifetime.IsNever && m.maxLifetime.Duration > 0 {
+		if limit := item.CreatedAt.Add(m.maxLifetime.Duration); expiresAt.After(limit) {
+			expiresAt = limit
+		}
+	}
+	if expiresAt.Sub(item.ExpiresAt) < slideThreshold {
+		return false
+	}
+
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
+	// 在文件中的最新内容上修改，item可能来自内存层，不能覆盖期间写入的新条目或刷新结果
+	filePath := filepath.Join(m.cacheDir, item.Key+".json")
+	current := m.readItem(filePath)
+	if current == nil || !current.CreatedAt.Equal(item.CreatedAt) {
+		return false
+	}
+	current.ExpiresAt = expiresAt
+	data, err := json.Marshal(current)
+	if err == nil {
+		err = utils.WriteFileAtomic(utils.StagingDir(m.cacheDir), filePath, data, 0644)
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to extend cache expiry %s: %v\n", item.Key, err)
+		return false
+	}
+	item.ExpiresAt = expiresAt
+	return true
+}
+
+// Storage 返回音频文件所在的存储后端
+func (m *Manager) Storage() storage.Storage {
+	return m.store
+}
+
+// Stats 返回内存层与数据库层的命中统计
+func (m *Manager) Stats() Stats {
+	return Stats{
+		Memory:         m.memoryStats.stats(),
+		Disk:           m.diskStats.stats(),
+		MemoryEntries:  m.memory.len(),
+		MemoryCapacity: m.memoryCapacity(),
+	}
+}
 
-	return item.Data
+// memoryCapacity 内存层容量，未启用时为0
+func (m *Manager) memoryCapacity() int {
+	if m.memory == nil {
+		return 0
+	}
+	return m.memory.capacity
 }
 
 // Set 设置缓存
-func (m *Manager) Set(bvid string, quality int, audioInfo *models.AudioInfo) error {
-	key := m.generateKey(bvid, quality)
+func (m *Manager) Set(spec Key, audioInfo *models.AudioInfo) error {
+	m.reconcileMu.RLock()
+	defer m.reconcileMu.RUnlock()
+
+	key := spec.Hash()
 	now := time.Now()
 
 	var expiresAt time.Time
@@ -138,49 +335,73 @@ func (m *Manager) Set(bvid string, quality int, audioInfo *models.AudioInfo) err
 		audioInfo.Expiring = int64(m.ttl.Duration.Seconds()) // 过期时间的秒数
 	}
 
+	// 补全解析结果，Hash不变
+	spec.CID, spec.Stream = audioInfo.CID, audioInfo.Quality
+
 	item := CacheItem{
-		Key:       key,
 		Data:      audioInfo,
 		CreatedAt: now,
 		ExpiresAt: expiresAt,
+
+		RefreshedAt: now,
 	}
+	item.applyKey(key, spec)
 
-	// 1. 写入缓存文件
+	// 1. 写入缓存文件 (覆盖前读取旧条目，稍后释放其文件引用)
 	filePath := filepath.Join(m.cacheDir, key+".json")
 	data, err := json.Marshal(item)
 	if err != nil {
 		return fmt.Errorf("failed to marshal cache item: %w", err)
 	}
 
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
+	m.writeMu.Lock()
+	previous := m.readItem(filePath)
+	if previous != nil && previous.Pinned {
+		// 重新解析不影响固定状态
+		item.Pinned = true
+		data, _ = json.Marshal(item)
+	}
+	err = utils.WriteFileAtomic(utils.StagingDir(m.cacheDir), filePath, data, 0644)
+	m.memory.remove(key)
+	m.writeMu.Unlock()
+	if err != nil {
 		return fmt.Errorf("failed to write cache file: %w", err)
 	}
 
 	// 2. 记录到数据库 (先删除旧记录，避免重复)
 	m.db.Where("cache_key = ?", key).Delete(&models.CacheRecord{})
 
-	record := models.CacheRecord{
-		CacheKey:  key,
-		BVID:      bvid,
-		Quality:   quality,
-		FilePath:  filePath,
-		ExpiresAt: item.ExpiresAt,
-	}
+	record := newRecord(&item, filePath)
+	record.LastAccessAt = now
 
 	if err := m.db.Create(&record).Error; err != nil {
 		// 数据库写入失败，但不删除缓存文件，因为缓存仍然有效
 		fmt.Printf("Warning: failed to save cache record to database: %v\n", err)
 	}
 
+	// 3. 更新文件引用计数，先增加新引用再释放旧引用，同一文件不会被误删
+	m.acquireAudioFile(audioInfo)
+	if previous != nil {
+		m.releaseAudioFiles(previous.Data)
+	}
+
+	// 4. 超出容量限制时在后台淘汰，连续写入只触发一次
+	if m.maxSize > 0 || m.maxFiles > 0 {
+		m.scheduleEviction()
+	}
+
 	return nil
 }
 
 // CleanupExpired 清理过期缓存
 func (m *Manager) CleanupExpired() error {
+	m.reconcileMu.RLock()
+	defer m.reconcileMu.RUnlock()
+
 	now := time.Now()
 	// 1. 从数据库中找到过期记录
 	var expiredRecords []models.CacheRecord
-	if err := m.db.Where("expires_at < ?", now).Find(&expiredRecords).Error; err != nil {
+	if err := m.db.Where("expires_at < ? AND pinned = ?", now, false).Find(&expiredRecords).Error; err != nil {
 		return fmt.Errorf("failed to find expired records: %w", err)
 	}
 
@@ -188,11 +409,11 @@ func (m *Manager) CleanupExpired() error {
 	// 2. 删除文件和数据库记录
 	for _, record := range expiredRecords {
 		// 先读取JSON文件获取MP3文件信息（在删除JSON文件之前）
-		var mp3FileName string
+		var audioInfo *models.AudioInfo
 		if data, err := os.ReadFile(record.FilePath); err == nil {
 			var item CacheItem
-			if json.Unmarshal(data, &item) == nil && item.Data != nil && item.Data.FileName != "" {
-				mp3FileName = item.Data.FileName
+			if json.Unmarshal(data, &item) == nil {
+				audioInfo = item.Data
 			}
 		}
 
@@ -203,20 +424,14 @@ func (m *Manager) CleanupExpired() error {
 			cleanedCount++
 		}
 
-		// 删除对应的MP3文件
-		if mp3FileName != "" {
-			mp3Path := filepath.Join(m.cacheDir, mp3FileName)
-			if err := os.Remove(mp3Path); err != nil && !os.IsNotExist(err) {
-				fmt.Printf("Warning: failed to remove MP3 file %s: %v\n", mp3Path, err)
-			} else {
-				fmt.Printf("Removed MP3 file: %s\n", mp3FileName)
-			}
-		}
+		// 释放对应的MP3及歌词等文件，无其他引用时删除
+		m.releaseAudioFiles(audioInfo)
 
 		// 删除数据库记录
 		if err := m.db.Delete(&record).Error; err != nil {
 			fmt.Printf("Warning: failed to delete cache record %d: %v\n", record.ID, err)
 		}
+		m.memory.remove(record.CacheKey)
 	}
 
 	if cleanedCount > 0 {
@@ -226,11 +441,77 @@ func (m *Manager) CleanupExpired() error {
 	return nil
 }
 
-// generateKey 生成缓存键
-func (m *Manager) generateKey(bvid string, quality int) string {
-	data := bvid + "_" + strconv.Itoa(quality)
-	hash := md5.Sum([]byte(data))
-	return fmt.Sprintf("%x", hash)
+// newRecord 根据缓存条目创建数据库记录
+func newRecord(item *CacheItem, filePath string) models.CacheRecord {
+	record := models.CacheRecord{
+		CacheKey:  item.Key,
+		BVID:      item.BVID,
+		Quality:   item.Quality,
+		Variant:   item.Variant,
+		FilePath:  filePath,
+		CreatedAt: item.CreatedAt,
+		ExpiresAt: item.ExpiresAt,
+
+		LastAccessAt: item.CreatedAt,
+		Pinned:       item.Pinned,
+
+		KeyVersion: item.Version,
+	}
+	if item.Spec != nil {
+		record.Page = item.Spec.Page
+		record.Account = item.Spec.Account
+		record.CID = item.Spec.CID
+		record.Stream = item.Spec.Stream
+	}
+	if item.Data != nil {
+		record.FileName = item.Data.FileName
+		if item.Data.Loudness != nil {
+			record.Loudness = item.Data.Loudness.Integrated
+			record.TruePeak = item.Data.Loudness.TruePeak
+		}
+	}
+	return record
+}
+
+// SweepTemp 删除崩溃或中断后残留的临时文件，仅在启动时调用 (此时没有进行中的转换)
+// 包括临时目录中的全部内容，以及旧版本直接写在缓存目录中的下载和章节临时文件
+func (m *Manager) SweepTemp() error {
+	removed := 0
+
+	stagingDir := utils.StagingDir(m.cacheDir)
+	entries, err := os.ReadDir(stagingDir)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read staging directory: %w", err)
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(stagingDir, entry.Name())); err != nil {
+			fmt.Printf("Warning: failed to remove stale temp file %s: %v\n", entry.Name(), err)
+			continue
+		}
+		removed++
+	}
+
+	entries, err = os.ReadDir(m.cacheDir)
+	if err != nil {
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, ".m4s") || strings.HasSuffix(name, ".ffmeta") || strings.HasSuffix(name, ".tmp")) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(m.cacheDir, name)); err != nil {
+			fmt.Printf("Warning: failed to remove stale temp file %s: %v\n", name, err)
+			continue
+		}
+		removed++
+	}
+
+	if removed > 0 {
+		fmt.Printf("Removed %d stale temp files\n", removed)
+	}
+
+	return nil
 }
 
 // StartCleanupWorker 启动清理工作协程
@@ -248,6 +529,9 @@ func (m *Manager) StartCleanupWorker(interval config.Duration) {
 			if err := m.CleanupExpired(); err != nil {
 				fmt.Printf("Cache cleanup error: %v\n", err)
 			}
+			if err := m.EnforceLimits(); err != nil {
+				fmt.Printf("Cache eviction error: %v\n", err)
+			}
 		}
 	}()
 }
@@ -258,12 +542,9 @@ func (m *Manager) cleanupCacheFiles(key string) {
 	jsonPath := filepath.Join(m.cacheDir, key+".json")
 	if data, err := os.ReadFile(jsonPath); err == nil {
 		var item CacheItem
-		if json.Unmarshal(data, &item) == nil && item.Data != nil && item.Data.FileName != "" {
-			// 删除对应的MP3文件
-			mp3Path := filepath.Join(m.cacheDir, item.Data.FileName)
-			if err := os.Remove(mp3Path); err != nil && !os.IsNotExist(err) {
-				fmt.Printf("Warning: failed to remove MP3 file %s: %v\n", mp3Path, err)
-			}
+		if json.Unmarshal(data, &item) == nil {
+			// 释放对应的MP3及歌词等文件，无其他引用时删除
+			m.releaseAudioFiles(item.Data)
 		}
 	}
 
@@ -274,4 +555,115 @@ func (m *Manager) cleanupCacheFiles(key string) {
 
 	// 清理相关的数据库记录
 	m.db.Where("cache_key = ?", key).Delete(&models.CacheRecord{})
+	m.memory.remove(key)
+}
+
+// writeItem 原子地写入缓存条目，调用方需持有writeMu
+func (m *Manager) writeItem(filePath string, item *CacheItem) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache item: %w", err)
+	}
+	if err := utils.WriteFileAtomic(utils.StagingDir(m.cacheDir), filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	return nil
+}
+
+// readItem 读取缓存条目，文件不存在或损坏时返回nil
+func (m *Manager) readItem(filePath string) *CacheItem {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+	var item CacheItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil
+	}
+	return &item
+}
+
+// acquireAudioFile 增加音频文件的引用计数，不存在时创建记录 (单条upsert语句，并发时不会丢失引用)
+func (m *Manager) acquireAudioFile(audioInfo *models.AudioInfo) {
+	if audioInfo == nil || audioInfo.FileName == "" {
+		return
+	}
+
+	file := models.AudioFile{
+		FileName: audioInfo.FileName,
+		Size:     audioInfo.Size,
+		RefCount: 1,
+	}
+	err := m.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "file_name"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"ref_count":  gorm.Expr("ref_count + 1"),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&file).Error
+	if err != nil {
+		fmt.Printf("Warning: failed to save audio file reference %s: %v\n", audioInfo.FileName, err)
+	}
+}
+
+// releaseAudioFiles 减少音频文件的引用计数，最后一个引用释放时删除文件并返回释放的字节数
+// 没有引用记录的旧缓存视为独占，直接删除
+func (m *Manager) releaseAudioFiles(audioInfo *models.AudioInfo) int64 {
+	if audioInfo == nil || audioInfo.FileName == "" {
+		return 0
+	}
+
+	// 在同一条语句中递减并取回新的计数，并发释放时每个调用方看到的计数各不相同
+	var files []models.AudioFile
+	result := m.db.Model(&files).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "ref_count"}}}).
+		Where("file_name = ?", audioInfo.FileName).
+		UpdateColumn("ref_count", gorm.Expr("ref_count - 1"))
+	if result.Error != nil {
+		fmt.Printf("Warning: failed to release audio file reference %s: %v\n", audioInfo.FileName, result.Error)
+		return 0
+	}
+
+	if result.RowsAffected > 0 {
+		if len(files) == 0 || files[0].RefCount > 0 {
+			return 0
+		}
+		// 只删除计数仍为0的记录，期间有新引用时保留文件
+		deleted := m.db.Where("file_name = ? AND ref_count <= ?", audioInfo.FileName, 0).Delete(&models.AudioFile{})
+		if deleted.Error != nil {
+			fmt.Printf("Warning: failed to delete audio file reference %s: %v\n", audioInfo.FileName, deleted.Error)
+			return 0
+		}
+		if deleted.RowsAffected == 0 {
+			return 0
+		}
+	}
+	return m.removeAudioFiles(audioInfo)
+}
+
+// removeAudioFiles 删除音频文件及其配套文件 (歌词等)，返回释放的字节数
+func (m *Manager) removeAudioFiles(audioInfo *models.AudioInfo) int64 {
+	if audioInfo == nil {
+		return 0
+	}
+
+	var freed int64
+	for _, fileName := range audioInfo.Files() {
+		info, err := m.store.Stat(fileName)
+		if errors.Is(err, os.ErrNotExist) {
+			// 文件已不存在，无需删除
+			continue
+		}
+		// HLS输出为子目录，存储后端会一并删除其中的文件
+		if err := m.store.Delete(fileName); err != nil {
+			fmt.Printf("Warning: failed to remove cached file %s: %v\n", fileName, err)
+			continue
+		}
+		// Stat失败 (非不存在) 时无法确认是否删除了内容，不记录也不计入释放量
+		if info != nil {
+			fmt.Printf("Removed cached file: %s\n", fileName)
+			freed += info.Size
+		}
+	}
+	return freed
 }
@@ -0,0 +1,249 @@
+package cache
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// accessFlushInterval 内存层命中的访问记录 (访问时间、命中次数) 写回数据库的间隔
+const accessFlushInterval = 30 * time.Second
+
+// memoryCache 缓存条目的内存层 (按条目数限制的LRU)，命中时不查询数据库也不读取JSON
+// 条目以JSON保存，每次读取得到独立副本，调用方修改返回值不影响缓存
+type memoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List               // 最近使用的在前
+	entries  map[string]*list.Element // 缓存键 -> order中的元素
+	version  uint64                   // 每次失效时递增，用于丢弃失效前读取的条目
+
+	pending   map[string]*pendingAccess // 尚未写回数据库的访问记录
+	flushedAt time.Time
+	flushing  int32
+}
+
+// memoryEntry 内存层中的一个条目
+type memoryEntry struct {
+	key       string
+	data      []byte // CacheItem的JSON
+	expiresAt time.Time
+	pinned    bool
+}
+
+// pendingAccess 内存层命中后待写回数据库的访问记录
+type pendingAccess struct {
+	lastAccessAt time.Time
+	hits         int64
+}
+
+// TierStats 单层缓存的命中统计
+type TierStats struct {
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// Stats 缓存命中统计，disk层只统计内存层未命中的请求
+type Stats struct {
+	Memory         TierStats `json:"memory"`
+	Disk           TierStats `json:"disk"`
+	MemoryEntries  int       `json:"memory_entries"`
+	MemoryCapacity int       `json:"memory_capacity"`
+}
+
+// tierCounters 单层缓存的命中计数
+type tierCounters struct {
+	hits   int64
+	misses int64
+}
+
+func (c *tierCounters) record(hit bool) {
+	if hit {
+		atomic.AddInt64(&c.hits, 1)
+	} else {
+		atomic.AddInt64(&c.misses, 1)
+	}
+}
+
+func (c *tierCounters) stats() TierStats {
+	stats := TierStats{Hits: atomic.LoadInt64(&c.hits), Misses: atomic.LoadInt64(&c.misses)}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// newMemoryCache 创建内存层，capacity<=0时返回nil表示不启用
+func newMemoryCache(capacity int) *memoryCache {
+	if capacity <= 0 {
+		return nil
+	}
+	return &memoryCache{
+		capacity:  capacity,
+		order:     list.New(),
+		entries:   make(map[string]*list.Element),
+		pending:   make(map[string]*pendingAccess),
+		flushedAt: time.Now(),
+	}
+}
+
+// get 返回未过期条目的副本并记录访问，不存在或已过期时返回nil
+func (c *memoryCache) get(key string, now time.Time) *CacheItem {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	element, ok := c.entries[key]
+	if !ok {
+		c.mu.Unlock()
+		return nil
+	}
+	entry := element.Value.(*memoryEntry)
+	if !entry.pinned && now.After(entry.expiresAt) {
+		// 过期条目交给lookup从数据库侧清理
+		c.removeLocked(key)
+		c.mu.Unlock()
+		return nil
+	}
+	c.order.MoveToFront(element)
+
+	access, ok := c.pending[key]
+	if !ok {
+		access = &pendingAccess{}
+		c.pending[key] = access
+	}
+	access.lastAccessAt = now
+	access.hits++
+	data := entry.data
+	c.mu.Unlock()
+
+	var item CacheItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil
+	}
+	return &item
+}
+
+// current 返回当前版本号，读取数据库与JSON前获取，写入内存层时传给put
+func (c *memoryCache) current() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.version
+}
+
+// put 写入条目，读取期间发生过失效 (版本号变化) 时放弃写入，避免旧内容覆盖失效
+func (c *memoryCache) put(item *CacheItem, version uint64) {
+	if c == nil {
+		return
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version != version {
+		return
+	}
+
+	entry := &memoryEntry{key: item.Key, data: data, expiresAt: item.ExpiresAt, pinned: item.Pinned}
+	if element, ok := c.entries[item.Key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[item.Key] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+// remove 使指定条目失效
+func (c *memoryCache) remove(key string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(key)
+}
+
+func (c *memoryCache) removeLocked(key string) {
+	c.version++
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// clear 使全部条目失效
+func (c *memoryCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+// len 当前条目数
+func (c *memoryCache) len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// flushDue 距上次写回访问记录是否已超过accessFlushInterval
+func (c *memoryCache) flushDue(now time.Time) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pending) > 0 && now.Sub(c.flushedAt) >= accessFlushInterval
+}
+
+// flushAccess 将内存层命中的访问时间与命中次数写回数据库，淘汰和列表依赖这些字段
+func (c *memoryCache) flushAccess(db *gorm.DB) {
+	if c == nil || !atomic.CompareAndSwapInt32(&c.flushing, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&c.flushing, 0)
+
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[string]*pendingAccess)
+	c.flushedAt = time.Now()
+	c.mu.Unlock()
+
+	for key, access := range pending {
+		err := db.Model(&models.CacheRecord{}).Where("cache_key = ?", key).UpdateColumns(map[string]interface{}{
+			"last_access_at": access.lastAccessAt,
+			"hit_count":      gorm.Expr("hit_count + ?", access.hits),
+		}).Error
+		if err != nil {
+			fmt.Printf("Warning: failed to record cache access %s: %v\n", key, err)
+		}
+	}
+}
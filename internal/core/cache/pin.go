@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// cacheKeyPattern 缓存键 (md5)
+var cacheKeyPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// IsKey 判断是否为缓存键，用于区分资源标识 (BV号、au号等) 与缓存键
+func IsKey(value string) bool {
+	return cacheKeyPattern.MatchString(value)
+}
+
+// SetPinned 固定或取消固定缓存条目，bvid匹配该资源的全部音质与变体，key匹配单个条目
+// 固定状态同时写入JSON条目，对账时不会丢失；取消固定的条目从当前时间重新计算过期时间
+// 返回更新后的记录，没有匹配的条目时返回空列表
+func (m *Manager) SetPinned(bvid, key string, pinned bool) ([]models.CacheRecord, error) {
+	query := m.db.Model(&models.CacheRecord{})
+	switch {
+	case key != "":
+		query = query.Where("cache_key = ?", key)
+	case bvid != "":
+		query = query.Where(&models.CacheRecord{BVID: bvid})
+	default:
+		return nil, fmt.Errorf("bvid or key is required")
+	}
+
+	var records []models.CacheRecord
+	if err := query.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to find cache records: %w", err)
+	}
+
+	updated := make([]models.CacheRecord, 0, len(records))
+	for _, record := range records {
+		expiresAt, err := m.pinItem(record.CacheKey, pinned)
+		if err != nil {
+			return updated, err
+		}
+
+		updates := map[string]interface{}{"pinned": pinned, "expires_at": expiresAt}
+		if err := m.db.Model(&record).UpdateColumns(updates).Error; err != nil {
+			return updated, fmt.Errorf("failed to update cache record %s: %w", record.CacheKey, err)
+		}
+		record.Pinned = pinned
+		record.ExpiresAt = expiresAt
+		updated = append(updated, record)
+	}
+	return updated, nil
+}
+
+// Pinned 列出全部固定的缓存记录
+func (m *Manager) Pinned() ([]models.CacheRecord, error) {
+	var records []models.CacheRecord
+	if err := m.db.Where("pinned = ?", true).Order("created_at DESC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to list pinned cache records: %w", err)
+	}
+	return records, nil
+}
+
+// pinItem 修改JSON条目的固定状态并返回其过期时间
+func (m *Manager) pinItem(key string, pinned bool) (time.Time, error) {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
+	filePath := filepath.Join(m.cacheDir, key+".json")
+	item := m.readItem(filePath)
+	if item == nil {
+		return time.Time{}, fmt.Errorf("cache entry %s is missing or corrupted", key)
+	}
+
+	item.Pinned = pinned
+	if !pinned && !m.ttl.IsNever {
+		// 固定期间可能早已过期，取消固定后保留一个完整的TTL
+		if expiresAt := time.Now().Add(m.ttl.Duration); expiresAt.After(item.ExpiresAt) {
+			item.ExpiresAt = expiresAt
+		}
+	}
+
+	if err := m.writeItem(filePath, item); err != nil {
+		return time.Time{}, err
+	}
+	m.memory.remove(key)
+	return item.ExpiresAt, nil
+}
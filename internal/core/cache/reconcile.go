@@ -0,0 +1,280 @@
+package cache
+
+import (
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/config"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/storage"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// reconcileGracePeriod 新近修改的文件可能属于进行中的转换 (已移入缓存目录但尚未写入缓存记录)，不视为孤儿
+const reconcileGracePeriod = 10 * time.Minute
+
+// cacheKeyFile 缓存条目JSON的文件名 (缓存键)
+var cacheKeyFile = regexp.MustCompile(`^[0-9a-f]{32}\.json$`)
+
+// cacheOwnedExtensions 缓存目录中由本服务生成的文件类型，孤儿清理只删除这些文件
+var cacheOwnedExtensions = map[string]bool{
+	".mp3": true, ".m4a": true, ".opus": true, ".flac": true, ".mka": true,
+	".lrc": true, ".vtt": true, ".json": true,
+}
+
+// ReconcileReport 一次对账的结果
+type ReconcileReport struct {
+	Entries         int      `json:"entries"`          // 对账后有效的缓存条目数
+	RecordsRemoved  int      `json:"records_removed"`  // 删除的无对应JSON的数据库记录
+	RecordsRepaired int      `json:"records_repaired"` // 根据JSON恢复的数据库记录
+	EntriesRemoved  int      `json:"entries_removed"`  // 删除的损坏、过期或缺少音频文件的JSON条目
+	OrphansRemoved  int      `json:"orphans_removed"`  // 删除的未被任何条目引用的文件
+	OrphansKept     int      `json:"orphans_kept"`     // 存储非本实例独占，未删除的未引用文件
+	RefsFixed       int      `json:"refs_fixed"`       // 修正的文件引用计数
+	FreedBytes      int64    `json:"freed_bytes"`      // 释放的磁盘空间
+	Errors          []string `json:"errors,omitempty"` // 对账过程中的错误
+	Duration        string   `json:"duration"`         // 耗时
+}
+
+func (r *ReconcileReport) errorf(format string, args ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+// String 对账结果摘要
+func (r *ReconcileReport) String() string {
+	return fmt.Sprintf("entries=%d records_removed=%d records_repaired=%d entries_removed=%d orphans_removed=%d orphans_kept=%d refs_fixed=%d freed_bytes=%d errors=%d duration=%s",
+		r.Entries, r.RecordsRemoved, r.RecordsRepaired, r.EntriesRemoved, r.OrphansRemoved, r.OrphansKept, r.RefsFixed, r.FreedBytes, len(r.Errors), r.Duration)
+}
+
+// Reconcile 对账数据库记录、JSON条目与音频文件三处状态:
+// 删除无JSON的记录和无记录且无法恢复的JSON，根据JSON补回缺失的记录，
+// 按有效条目重建文件引用计数，并删除未被任何条目引用的孤儿文件
+// 对账期间独占缓存，并发的写入与删除等待对账完成，避免误删新条目或覆盖期间变化的引用计数
+func (m *Manager) Reconcile() (*ReconcileReport, error) {
+	m.reconcileMu.Lock()
+	defer m.reconcileMu.Unlock()
+
+	start := time.Now()
+	report := &ReconcileReport{}
+
+	// 1. 读取全部JSON条目，再列出存储中的文件
+	// 音频文件先于JSON条目写入，先读条目后列文件可避免把刚完成的条目误判为缺少文件
+	dirEntries, err := os.ReadDir(m.cacheDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	read := make(map[string]*CacheItem)
+	for _, entry := range dirEntries {
+		if entry.IsDir() || !cacheKeyFile.MatchString(entry.Name()) {
+			continue
+		}
+		read[strings.TrimSuffix(entry.Name(), ".json")] = m.readItem(filepath.Join(m.cacheDir, entry.Name()))
+	}
+
+	stored, err := m.storedNames()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make(map[string]*CacheItem)
+	for key, item := range read {
+		if item == nil || item.Data == nil || item.Data.FileName == "" || !stored[item.Data.FileName] || item.expired(time.Now()) {
+			// 损坏、缺少音频文件或已过期，引用计数在第4步统一重建
+			m.removeCounted(report, filepath.Join(m.cacheDir, key+".json"))
+			report.EntriesRemoved++
+			continue
+		}
+		items[key] = item
+	}
+
+	// 2. 对账数据库记录
+	var records []models.CacheRecord
+	if err := m.db.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to load cache records: %w", err)
+	}
+
+	recorded := make(map[string]bool, len(records))
+	for _, record := range records {
+		item, ok := items[record.CacheKey]
+		if !ok || recorded[record.CacheKey] {
+			// 没有有效的JSON条目，或同一键的重复记录
+			if err := m.db.Delete(&record).Error; err != nil {
+				report.errorf("delete record %d: %v", record.ID, err)
+				continue
+			}
+			report.RecordsRemoved++
+			continue
+		}
+		recorded[record.CacheKey] = true
+
+		// 固定状态以JSON为准 (固定时先写JSON再写记录)
+		if record.Pinned != item.Pinned {
+			if err := m.db.Model(&record).UpdateColumn("pinned", item.Pinned).Error; err != nil {
+				report.errorf("update pin %s: %v", record.CacheKey, err)
+				continue
+			}
+			report.RecordsRepaired++
+		}
+	}
+
+	// 3. 根据JSON补回缺失的记录
+	for key, item := range items {
+		if recorded[key] {
+			continue
+		}
+		record := newRecord(item, filepath.Join(m.cacheDir, key+".json"))
+		record.CacheKey = key
+		if err := m.db.Create(&record).Error; err != nil {
+			report.errorf("repair record %s: %v", key, err)
+			continue
+		}
+		report.RecordsRepaired++
+	}
+
+	// 4. 按有效条目重建文件引用计数
+	refs := make(map[string]int)
+	sizes := make(map[string]int64)
+	referenced := make(map[string]bool)
+	for key, item := range items {
+		refs[item.Data.FileName]++
+		sizes[item.Data.FileName] = item.Data.Size
+		referenced[key+".json"] = true
+		for _, name := range item.Data.Files() {
+			referenced[name] = true
+		}
+	}
+	m.rebuildRefs(report, refs, sizes)
+
+	// 5. 删除存储中的孤儿文件，存储非本实例独占时本地索引不完整，只统计不删除
+	objects, err := m.store.List()
+	if err != nil {
+		report.errorf("list storage: %v", err)
+	}
+	exclusive := m.store.Exclusive()
+	for _, object := range objects {
+		if referenced[object.Name] || !isCacheOwned(object) || time.Since(object.ModTime) < reconcileGracePeriod {
+			continue
+		}
+		if !exclusive {
+			report.OrphansKept++
+			continue
+		}
+		if err := m.store.Delete(object.Name); err != nil {
+			report.errorf("remove %s: %v", object.Name, err)
+			continue
+		}
+		report.FreedBytes += object.Size
+		report.OrphansRemoved++
+	}
+
+	// 内存层可能仍保存已删除或修正的条目，全部失效后重新从文件读取
+	m.memory.clear()
+
+	report.Entries = len(items)
+	report.Duration = time.Since(start).Round(time.Millisecond).String()
+	return report, nil
+}
+
+// rebuildRefs 使引用计数表与实际引用一致
+func (m *Manager) rebuildRefs(report *ReconcileReport, refs map[string]int, sizes map[string]int64) {
+	var files []models.AudioFile
+	if err := m.db.Find(&files).Error; err != nil {
+		report.errorf("load audio file refs: %v", err)
+		return
+	}
+
+	for _, file := range files {
+		count, ok := refs[file.FileName]
+		delete(refs, file.FileName)
+		switch {
+		case !ok:
+			if err := m.db.Delete(&file).Error; err != nil {
+				report.errorf("delete ref %s: %v", file.FileName, err)
+				continue
+			}
+			report.RefsFixed++
+		case file.RefCount != count:
+			if err := m.db.Model(&file).UpdateColumn("ref_count", count).Error; err != nil {
+				report.errorf("update ref %s: %v", file.FileName, err)
+				continue
+			}
+			report.RefsFixed++
+		}
+	}
+
+	for name, count := range refs {
+		file := models.AudioFile{FileName: name, Size: sizes[name], RefCount: count}
+		if err := m.db.Create(&file).Error; err != nil {
+			report.errorf("create ref %s: %v", name, err)
+			continue
+		}
+		report.RefsFixed++
+	}
+}
+
+// storedNames 存储中全部顶层条目的名称
+func (m *Manager) storedNames() (map[string]bool, error) {
+	objects, err := m.store.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage: %w", err)
+	}
+	names := make(map[string]bool, len(objects))
+	for _, object := range objects {
+		names[object.Name] = true
+	}
+	return names, nil
+}
+
+// removeCounted 删除本地文件并统计释放的空间
+func (m *Manager) removeCounted(report *ReconcileReport, path string) bool {
+	size := pathSize(path)
+	if err := os.RemoveAll(path); err != nil {
+		report.errorf("remove %s: %v", filepath.Base(path), err)
+		return false
+	}
+	report.FreedBytes += size
+	return true
+}
+
+// isCacheOwned 是否为本服务生成的缓存文件 (音频、歌词、JSON及HLS目录)
+func isCacheOwned(object storage.ObjectInfo) bool {
+	if object.IsDir {
+		return strings.HasSuffix(object.Name, "_hls")
+	}
+	return cacheOwnedExtensions[strings.ToLower(filepath.Ext(object.Name))]
+}
+
+// pathSize 文件或目录占用的字节数
+func pathSize(path string) int64 {
+	var size int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// StartReconcileWorker 启动定期对账协程
+func (m *Manager) StartReconcileWorker(interval config.Duration) {
+	if interval.IsNever || interval.Duration <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval.Duration)
+	go func() {
+		defer ticker.Stop()
+		for range ticker.C {
+			report, err := m.Reconcile()
+			if err != nil {
+				fmt.Printf("Cache reconcile error: %v\n", err)
+				continue
+			}
+			fmt.Printf("Cache reconciled: %s\n", report)
+		}
+	}()
+}
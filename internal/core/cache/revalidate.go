@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/storage"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// urlExpiryMargin 原始链接剩余有效期不足该值时视为已过期，不再返回给客户端
+	urlExpiryMargin = 5 * time.Minute
+	// revalidateRetry 同一条目两次后台刷新之间的最小间隔 (刷新失败时避免每次命中都请求B站)
+	revalidateRetry = time.Minute
+)
+
+// Revalidator 重新获取缓存条目的元数据与原始链接，不下载也不转码
+type Revalidator func() (*models.AudioInfo, error)
+
+// GetFresh 获取缓存 (stale-while-revalidate)：元数据超过metadata_ttl或原始链接即将过期时，
+// 仍立即返回缓存的音频与元数据，同时在后台调用revalidate刷新条目；已过期的原始链接不会返回
+// revalidate为nil时不刷新
+func (m *Manager) GetFresh(spec Key, revalidate Revalidator) *models.AudioInfo {
+	key := spec.Hash()
+	item := m.lookup(key)
+	if item == nil || item.Data == nil {
+		return nil
+	}
+
+	now := time.Now()
+	if revalidate != nil && m.isStale(item, now) {
+		m.revalidate(key, revalidate)
+	}
+
+	hideExpiredURL(item.Data, now)
+	// 预签名链接会过期，每次按当前存储配置重新生成
+	storage.ResolveURLs(m.store, item.Data)
+	return item.Data
+}
+
+// isStale 条目的元数据或原始链接是否需要刷新
+func (m *Manager) isStale(item *CacheItem, now time.Time) bool {
+	if urlExpired(item.Data, now) {
+		return true
+	}
+	if m.metadataTTL.IsNever || m.metadataTTL.Duration <= 0 {
+		return false
+	}
+
+	refreshedAt := item.RefreshedAt
+	if refreshedAt.IsZero() {
+		refreshedAt = item.CreatedAt
+	}
+	return now.Sub(refreshedAt) > m.metadataTTL.Duration
+}
+
+// revalidate 在后台刷新条目，同一条目同时只有一个刷新任务
+func (m *Manager) revalidate(key string, fn Revalidator) {
+	m.revalidateMu.Lock()
+	if _, ok := m.revalidating[key]; ok {
+		m.revalidateMu.Unlock()
+		return
+	}
+	m.revalidating[key] = time.Now()
+	m.revalidateMu.Unlock()
+
+	go func() {
+		fresh, err := fn()
+		if err != nil {
+			// revalidateRetry内不再重试，之后删除记录
+			fmt.Printf("Warning: failed to revalidate cache entry %s: %v\n", key, err)
+			time.AfterFunc(revalidateRetry, func() {
+				m.revalidateMu.Lock()
+				delete(m.revalidating, key)
+				m.revalidateMu.Unlock()
+			})
+			return
+		}
+
+		if err := m.refresh(key, fresh); err != nil {
+			fmt.Printf("Warning: failed to refresh cache entry %s: %v\n", key, err)
+		}
+
+		m.revalidateMu.Lock()
+		delete(m.revalidating, key)
+		m.revalidateMu.Unlock()
+	}()
+}
+
+// refresh 将重新获取的元数据与原始链接写回缓存条目，音频文件与过期时间不变
+func (m *Manager) refresh(key string, fresh *models.AudioInfo) error {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
+	filePath := filepath.Join(m.cacheDir, key+".json")
+	item := m.readItem(filePath)
+	if item == nil || item.Data == nil {
+		// 刷新期间条目已被删除
+		return nil
+	}
+
+	if fresh.OriginalURL != "" {
+		item.Data.OriginalURL = fresh.OriginalURL
+		item.Data.OriginalURLExpires = fresh.OriginalURLExpires
+	}
+	if fresh.Title != "" {
+		item.Data.Title = fresh.Title
+	}
+	if fresh.Artist != "" {
+		item.Data.Artist = fresh.Artist
+	}
+	if fresh.Cover != "" {
+		item.Data.Cover = fresh.Cover
+	}
+	if fresh.LyricURL != "" {
+		item.Data.LyricURL = fresh.LyricURL
+	}
+	item.RefreshedAt = time.Now()
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache item: %w", err)
+	}
+	if err := utils.WriteFileAtomic(utils.StagingDir(m.cacheDir), filePath, data, 0644); err != nil {
+		return err
+	}
+	m.memory.remove(key)
+	return nil
+}
+
+// urlExpiresAt 原始链接的过期时间，旧版本缓存条目没有记录时从链接中解析
+func urlExpiresAt(info *models.AudioInfo) time.Time {
+	if info.OriginalURLExpires > 0 {
+		return time.Unix(info.OriginalURLExpires, 0)
+	}
+	return utils.URLDeadline(info.OriginalURL)
+}
+
+// urlExpired 原始链接是否已过期或即将过期，过期时间未知时视为有效
+func urlExpired(info *models.AudioInfo, now time.Time) bool {
+	if info.OriginalURL == "" {
+		return false
+	}
+	expiresAt := urlExpiresAt(info)
+	return !expiresAt.IsZero() && now.After(expiresAt.Add(-urlExpiryMargin))
+}
+
+// hideExpiredURL 清空已过期的原始链接，未过期时补全过期时间
+func hideExpiredURL(info *models.AudioInfo, now time.Time) {
+	if urlExpired(info, now) {
+		info.OriginalURL = ""
+		info.OriginalURLExpires = 0
+		return
+	}
+	if expiresAt := urlExpiresAt(info); !expiresAt.IsZero() {
+		info.OriginalURLExpires = expiresAt.Unix()
+	}
+}
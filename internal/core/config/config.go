@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -42,6 +43,40 @@ func (d Duration) String() string {
 	return d.Duration.String()
 }
 
+// ByteSize 字节数，支持 "500MB"、"10GB" 等写法，0表示不限制
+type ByteSize int64
+
+// UnmarshalText 实现text unmarshaler接口
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	s := strings.ToUpper(strings.TrimSpace(string(text)))
+	if s == "" || s == "0" {
+		*b = 0
+		return nil
+	}
+
+	units := []struct {
+		suffix string
+		factor int64
+	}{
+		{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1},
+	}
+	factor := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(s, unit.suffix) {
+			factor = unit.factor
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return fmt.Errorf("invalid size %q", string(text))
+	}
+	*b = ByteSize(value * float64(factor))
+	return nil
+}
+
 type Config struct {
 	Server    ServerConfig    `mapstructure:"server"`
 	Database  DatabaseConfig  `mapstructure:"database"`
@@ -50,6 +85,12 @@ type Config struct {
 	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
 	CORS      CORSConfig      `mapstructure:"cors"`
 	Logging   LoggingConfig   `mapstructure:"logging"`
+	Live      LiveConfig      `mapstructure:"live"`
+	Transcode TranscodeConfig `mapstructure:"transcode"`
+	Storage   StorageConfig   `mapstructure:"storage"`
+	Auth      AuthConfig      `mapstructure:"auth"`
+	Admin     AdminConfig     `mapstructure:"admin"`
+	Prewarm   PrewarmConfig   `mapstructure:"prewarm"`
 }
 
 type ServerConfig struct {
@@ -64,9 +105,59 @@ type DatabaseConfig struct {
 }
 
 type CacheConfig struct {
-	Dir             string   `mapstructure:"dir"`
-	TTL             Duration `mapstructure:"-"`
-	CleanupInterval Duration `mapstructure:"-"`
+	Dir               string   `mapstructure:"dir"`
+	TTL               Duration `mapstructure:"-"`
+	CleanupInterval   Duration `mapstructure:"-"`
+	ReconcileInterval Duration `mapstructure:"-"` // 数据库记录、JSON条目与文件的对账间隔
+
+	SlidingTTL  bool     `mapstructure:"sliding_ttl"` // 命中时将过期时间顺延为 当前时间+ttl
+	MaxLifetime Duration `mapstructure:"-"`           // 顺延的上限，从创建时间算起，"never" 表示不限制
+	MetadataTTL Duration `mapstructure:"-"`           // 元数据 (标题、封面) 与原始链接的刷新间隔，"never" 表示只在原始链接过期时刷新
+
+	MemoryEntries int `mapstructure:"memory_entries"` // 内存层缓存的最大条目数，0表示不启用
+
+	MaxSize  ByteSize `mapstructure:"-"`         // 缓存目录最大占用空间，0表示不限制
+	MaxFiles int      `mapstructure:"max_files"` // 最大音频文件数，0表示不限制
+	Eviction string   `mapstructure:"eviction"`  // 超出限制时的淘汰策略 lru / lfu
+}
+
+// AuthConfig 解析接口的访问令牌鉴权配置
+type AuthConfig struct {
+	Enabled bool     `mapstructure:"enabled"` // 是否要求访问令牌，/static、/status与/health始终公开
+	Tokens  []string `mapstructure:"tokens"`  // 静态令牌，另可通过管理接口或命令行创建保存在数据库中的访问密钥
+}
+
+// AdminConfig 管理接口配置
+type AdminConfig struct {
+	Token string `mapstructure:"token"` // 管理接口令牌，为空时不开放管理接口
+}
+
+// PrewarmConfig 缓存预热配置
+type PrewarmConfig struct {
+	Delay     time.Duration `mapstructure:"delay"`      // 每次实际解析后的等待时间
+	QueueSize int           `mapstructure:"queue_size"` // 等待中的预热任务上限
+	MaxPages  int           `mapstructure:"max_pages"`  // 每个来源最多抓取的页面数 (sitemap中的页面及短链接)
+}
+
+// StorageConfig 转换输出的存储后端
+type StorageConfig struct {
+	Type string   `mapstructure:"type"` // local / s3
+	S3   S3Config `mapstructure:"s3"`
+}
+
+// S3Config S3兼容对象存储配置
+type S3Config struct {
+	Endpoint      string        `mapstructure:"endpoint"`       // 如 https://s3.amazonaws.com、http://127.0.0.1:9000
+	Region        string        `mapstructure:"region"`         // 签名使用的区域
+	Bucket        string        `mapstructure:"bucket"`         // 存储桶
+	Prefix        string        `mapstructure:"prefix"`         // 对象键前缀
+	Replica       string        `mapstructure:"replica"`        // 副本标识，多个副本共用存储桶时各自设置不同的值，输出保存在 <prefix>/<replica>/ 下
+	AccessKey     string        `mapstructure:"access_key"`     // 访问密钥ID
+	SecretKey     string        `mapstructure:"secret_key"`     // 访问密钥
+	PathStyle     bool          `mapstructure:"path_style"`     // 存储桶名放在路径中 (MinIO等)
+	URLMode       string        `mapstructure:"url_mode"`       // presign / proxy / public
+	PublicURL     string        `mapstructure:"public_url"`     // url_mode为public时的访问地址 (公开读的存储桶或CDN)
+	PresignExpiry time.Duration `mapstructure:"presign_expiry"` // 预签名链接有效期
 }
 
 type BilibiliConfig struct {
@@ -96,6 +187,53 @@ type LoggingConfig struct {
 	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`
 }
 
+type LiveConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	APIBase        string        `mapstructure:"api_base"`        // 直播API地址，可指向本地模拟源
+	Format         string        `mapstructure:"format"`          // aac (转封装) / mp3 (转码)
+	Bitrate        int           `mapstructure:"bitrate"`         // mp3转码比特率 (kbps)
+	ReconnectDelay time.Duration `mapstructure:"reconnect_delay"` // 上游断开后的重连间隔
+	MaxReconnects  int           `mapstructure:"max_reconnects"`  // 连续重连失败次数上限
+	MaxListeners   int           `mapstructure:"max_listeners"`   // 单个直播间最大听众数，0表示不限制
+}
+
+type TranscodeConfig struct {
+	Loudness LoudnessConfig `mapstructure:"loudness"`
+	Waveform WaveformConfig `mapstructure:"waveform"`
+	HLS      HLSConfig      `mapstructure:"hls"`
+
+	Profiles       map[string]ProfileConfig `mapstructure:"profiles"`        // 命名转码配置，请求通过 profile= 选择
+	DefaultProfile string                   `mapstructure:"default_profile"` // 未指定profile时使用的配置，为空时按源码率输出MP3
+}
+
+type LoudnessConfig struct {
+	TargetI    float64 `mapstructure:"target_i"`   // 目标综合响度 (LUFS)
+	TargetTP   float64 `mapstructure:"target_tp"`  // 目标真峰值 (dBTP)
+	TargetLRA  float64 `mapstructure:"target_lra"` // 目标响度范围 (LU)
+	ReplayGain bool    `mapstructure:"replaygain"` // 是否测量响度并写入ReplayGain标签 (不重新编码响度)
+}
+
+type WaveformConfig struct {
+	Enabled         bool  `mapstructure:"enabled"`
+	SampleRate      int   `mapstructure:"sample_rate"`       // 解码采样率
+	SamplesPerPixel []int `mapstructure:"samples_per_pixel"` // 各级分辨率的每像素采样数
+}
+
+type ProfileConfig struct {
+	Codec      string `mapstructure:"codec"`       // mp3 / aac / opus / flac / copy
+	Bitrate    int    `mapstructure:"bitrate"`     // CBR码率(kbps)，0表示按源码率
+	Quality    *int   `mapstructure:"quality"`     // VBR质量 (ffmpeg -q:a)，设置后忽略bitrate
+	SampleRate int    `mapstructure:"sample_rate"` // 输出采样率，0表示保持源采样率
+	Channels   int    `mapstructure:"channels"`    // 输出声道数，0表示保持源声道数
+	Filters    string `mapstructure:"filters"`     // 额外的ffmpeg音频滤镜
+}
+
+type HLSConfig struct {
+	SegmentType     string `mapstructure:"segment_type"`     // fmp4 / ts
+	SegmentDuration int    `mapstructure:"segment_duration"` // 分片时长(秒)
+	Bitrates        []int  `mapstructure:"bitrates"`         // 各档AAC码率(kbps)，为空时使用源码率单档输出
+}
+
 func LoadConfig() (*Config, error) {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
@@ -141,6 +279,36 @@ func parseCacheDurations(config *Config) error {
 		return fmt.Errorf("invalid cache.cleanup_interval value '%s': %w", cleanupStr, err)
 	}
 
+	// 解析 cache.reconcile_interval
+	reconcileStr := viper.GetString("cache.reconcile_interval")
+	if err := config.Cache.ReconcileInterval.UnmarshalText([]byte(reconcileStr)); err != nil {
+		return fmt.Errorf("invalid cache.reconcile_interval value '%s': %w", reconcileStr, err)
+	}
+
+	// 解析 cache.max_lifetime
+	maxLifetimeStr := viper.GetString("cache.max_lifetime")
+	if err := config.Cache.MaxLifetime.UnmarshalText([]byte(maxLifetimeStr)); err != nil {
+		return fmt.Errorf("invalid cache.max_lifetime value '%s': %w", maxLifetimeStr, err)
+	}
+
+	// 解析 cache.metadata_ttl
+	metadataTTLStr := viper.GetString("cache.metadata_ttl")
+	if err := config.Cache.MetadataTTL.UnmarshalText([]byte(metadataTTLStr)); err != nil {
+		return fmt.Errorf("invalid cache.metadata_ttl value '%s': %w", metadataTTLStr, err)
+	}
+
+	// 解析 cache.max_size
+	maxSizeStr := viper.GetString("cache.max_size")
+	if err := config.Cache.MaxSize.UnmarshalText([]byte(maxSizeStr)); err != nil {
+		return fmt.Errorf("invalid cache.max_size value '%s': %w", maxSizeStr, err)
+	}
+
+	switch config.Cache.Eviction {
+	case "lru", "lfu":
+	default:
+		return fmt.Errorf("invalid cache.eviction value '%s': must be lru or lfu", config.Cache.Eviction)
+	}
+
 	return nil
 }
 
@@ -158,6 +326,14 @@ func setDefaults() {
 	viper.SetDefault("cache.dir", "./parse_cache")
 	viper.SetDefault("cache.ttl", "1h")
 	viper.SetDefault("cache.cleanup_interval", "30m")
+	viper.SetDefault("cache.reconcile_interval", "6h")
+	viper.SetDefault("cache.sliding_ttl", false)
+	viper.SetDefault("cache.max_lifetime", "168h")
+	viper.SetDefault("cache.metadata_ttl", "1h")
+	viper.SetDefault("cache.memory_entries", 1000)
+	viper.SetDefault("cache.max_size", "0")
+	viper.SetDefault("cache.max_files", 0)
+	viper.SetDefault("cache.eviction", "lru")
 
 	// Bilibili defaults
 	viper.SetDefault("bilibili.user_agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
@@ -181,6 +357,43 @@ func setDefaults() {
 	viper.SetDefault("logging.max_backups", 7)
 	viper.SetDefault("logging.max_age", 30)
 	viper.SetDefault("logging.cleanup_interval", "24h")
+
+	// Live defaults
+	viper.SetDefault("live.enabled", true)
+	viper.SetDefault("live.api_base", "https://api.live.bilibili.com")
+	viper.SetDefault("live.format", "aac")
+	viper.SetDefault("live.bitrate", 128)
+	viper.SetDefault("live.reconnect_delay", "3s")
+	viper.SetDefault("live.max_reconnects", 5)
+	viper.SetDefault("live.max_listeners", 0)
+
+	// Transcode defaults
+	viper.SetDefault("transcode.loudness.target_i", -16.0)
+	viper.SetDefault("transcode.loudness.target_tp", -1.5)
+	viper.SetDefault("transcode.loudness.target_lra", 11.0)
+	viper.SetDefault("transcode.loudness.replaygain", false)
+	viper.SetDefault("transcode.waveform.enabled", true)
+	viper.SetDefault("transcode.waveform.sample_rate", 22050)
+	viper.SetDefault("transcode.waveform.samples_per_pixel", []int{256, 1024, 4096})
+	viper.SetDefault("transcode.hls.segment_type", "fmp4")
+	viper.SetDefault("transcode.hls.segment_duration", 6)
+	viper.SetDefault("transcode.hls.bitrates", []int{64, 128})
+
+	// Storage defaults
+	viper.SetDefault("storage.type", "local")
+	viper.SetDefault("storage.s3.region", "us-east-1")
+	viper.SetDefault("storage.s3.url_mode", "presign")
+	viper.SetDefault("storage.s3.presign_expiry", "1h")
+
+	// Auth defaults
+	viper.SetDefault("auth.enabled", false)
+	viper.SetDefault("auth.tokens", []string{})
+
+	// Admin and prewarm defaults
+	viper.SetDefault("admin.token", "")
+	viper.SetDefault("prewarm.delay", "5s")
+	viper.SetDefault("prewarm.queue_size", 10)
+	viper.SetDefault("prewarm.max_pages", 200)
 }
 
 // createDefaultConfig 创建默认配置文件
@@ -205,6 +418,14 @@ cache:
   dir: "./parse_cache"
   ttl: "24h"        # 缓存过期时间，支持 "never" 表示永不过期
   cleanup_interval: "1h"  # 清理间隔，支持 "never" 表示永不清理
+  reconcile_interval: "6h"  # 对账间隔，支持 "never" 表示只在启动时对账
+  sliding_ttl: false  # 命中时顺延过期时间
+  max_lifetime: "168h"  # 顺延的上限 (从创建时间算起)，支持 "never" 表示不限制
+  metadata_ttl: "1h"  # 元数据与原始链接的后台刷新间隔，支持 "never" 表示只在原始链接过期时刷新
+  memory_entries: 1000  # 内存层缓存的最大条目数，0表示不启用
+  max_size: "0"     # 缓存最大占用空间，如 "10GB"，0表示不限制
+  max_files: 0      # 最大音频文件数，0表示不限制
+  eviction: "lru"   # 超出限制时的淘汰策略 lru (最久未访问) / lfu (访问次数最少)
 
 bilibili:
   user_agent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
@@ -228,6 +449,21 @@ logging:
   max_backups: 7
   max_age: 30
   cleanup_interval: "24h"
+
+storage:
+  type: "local"          # local / s3
+
+auth:
+  enabled: false         # 是否要求访问令牌
+  tokens: []             # 静态令牌
+
+admin:
+  token: ""              # 管理接口令牌，为空时不开放管理接口
+
+prewarm:
+  delay: "5s"            # 每次实际解析后的等待时间
+  queue_size: 10         # 等待中的预热任务上限
+  max_pages: 200         # 每个来源最多抓取的页面数
 `
 
 	configFile := filepath.Join(configDir, "config.yaml")
@@ -0,0 +1,305 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	chunkSize        = 16 * 1024 // 每次从ffmpeg读取的数据块大小
+	subscriberBuffer = 128       // 每个听众的缓冲块数，缓冲满时断开该听众而不阻塞其他听众
+)
+
+// ffmpegCommand 创建拉流转封装的ffmpeg进程，测试中替换为不依赖ffmpeg的模拟进程
+var ffmpegCommand = exec.CommandContext
+
+// ErrTooManyListeners 直播间听众已满
+var ErrTooManyListeners = errors.New("too many listeners for this live room")
+
+// Manager 直播音频转发管理器，同一直播间的所有听众共享一个上游连接
+type Manager struct {
+	cfg       config.LiveConfig
+	resolver  *Resolver
+	userAgent string
+	referer   string
+
+	mu     sync.Mutex
+	relays map[int64]*relay
+}
+
+// NewManager 创建直播音频转发管理器
+func NewManager(cfg config.LiveConfig, userAgent, referer string) *Manager {
+	return &Manager{
+		cfg:       cfg,
+		resolver:  NewResolver(cfg.APIBase, userAgent, referer),
+		userAgent: userAgent,
+		referer:   referer,
+		relays:    make(map[int64]*relay),
+	}
+}
+
+// ContentType 返回转发音频流的MIME类型
+func (m *Manager) ContentType() string {
+	if m.cfg.Format == "mp3" {
+		return "audio/mpeg"
+	}
+	return "audio/aac"
+}
+
+// Subscribe 订阅直播间音频流，roomID可以是短号
+func (m *Manager) Subscribe(roomID int64) (*Subscriber, error) {
+	realID, err := m.resolver.ResolveRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, exists := m.relays[realID]
+	if !exists {
+		ctx, cancel := context.WithCancel(context.Background())
+		r = &relay{
+			roomID:      realID,
+			manager:     m,
+			subscribers: make(map[*Subscriber]struct{}),
+			cancel:      cancel,
+		}
+		m.relays[realID] = r
+		go r.run(ctx)
+		logrus.Infof("Live relay started for room %d", realID)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m.cfg.MaxListeners > 0 && len(r.subscribers) >= m.cfg.MaxListeners {
+		return nil, ErrTooManyListeners
+	}
+
+	sub := &Subscriber{
+		ch:    make(chan []byte, subscriberBuffer),
+		relay: r,
+	}
+	r.subscribers[sub] = struct{}{}
+
+	return sub, nil
+}
+
+// Stats 返回当前各直播间的听众数
+func (m *Manager) Stats() map[int64]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make(map[int64]int, len(m.relays))
+	for id, r := range m.relays {
+		r.mu.Lock()
+		stats[id] = len(r.subscribers)
+		r.mu.Unlock()
+	}
+	return stats
+}
+
+// unsubscribe 移除听众，最后一个听众离开时停止上游连接
+func (m *Manager) unsubscribe(sub *Subscriber) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r := sub.relay
+	r.mu.Lock()
+	delete(r.subscribers, sub)
+	empty := len(r.subscribers) == 0
+	r.mu.Unlock()
+
+	if empty && m.relays[r.roomID] == r {
+		delete(m.relays, r.roomID)
+		r.cancel()
+		logrus.Infof("Live relay stopped for room %d: no listeners", r.roomID)
+	}
+}
+
+// Subscriber 直播音频流的听众
+type Subscriber struct {
+	ch     chan []byte
+	relay  *relay
+	once   sync.Once
+	closed bool // 由relay.mu保护
+}
+
+// C 返回音频数据通道，通道关闭表示直播流已结束
+func (s *Subscriber) C() <-chan []byte {
+	return s.ch
+}
+
+// Close 取消订阅
+func (s *Subscriber) Close() {
+	s.once.Do(func() {
+		s.relay.manager.unsubscribe(s)
+	})
+}
+
+// relay 单个直播间的上游连接
+type relay struct {
+	roomID  int64
+	manager *Manager
+	cancel  context.CancelFunc
+
+	mu          sync.Mutex
+	subscribers map[*Subscriber]struct{}
+}
+
+// run 拉取上游直播流，断开时自动重连，直到没有听众或连续失败次数超限
+func (r *relay) run(ctx context.Context) {
+	defer r.closeAll()
+
+	failures := 0
+	for {
+		startedAt := time.Now()
+		err := r.stream(ctx)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if errors.Is(err, ErrRoomNotLive) {
+			logrus.Infof("Live relay for room %d ended: room went offline", r.roomID)
+			return
+		}
+
+		// 稳定运行过一段时间后断开，视为新的一轮重连
+		if time.Since(startedAt) > time.Minute {
+			failures = 0
+		}
+		failures++
+
+		if r.manager.cfg.MaxReconnects > 0 && failures > r.manager.cfg.MaxReconnects {
+			logrus.Warnf("Live relay for room %d gave up after %d reconnects: %v", r.roomID, failures-1, err)
+			return
+		}
+
+		logrus.Warnf("Live relay for room %d disconnected, reconnecting (%d): %v", r.roomID, failures, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.manager.cfg.ReconnectDelay):
+		}
+	}
+}
+
+// stream 建立一次上游连接并将音频数据分发给所有听众
+func (r *relay) stream(ctx context.Context) error {
+	streamURL, err := r.manager.resolver.ResolveStreamURL(r.roomID)
+	if err != nil {
+		return err
+	}
+
+	cmd := ffmpegCommand(ctx, "ffmpeg", r.ffmpegArgs(streamURL)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create ffmpeg pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, readErr := stdout.Read(buf)
+		if n > 0 {
+			chunk := make([]byte, n)
+			copy(chunk, buf[:n])
+			r.broadcast(chunk)
+		}
+		if readErr != nil {
+			waitErr := cmd.Wait()
+			if readErr == io.EOF && waitErr != nil {
+				return fmt.Errorf("ffmpeg exited: %w", waitErr)
+			}
+			if readErr == io.EOF {
+				return fmt.Errorf("upstream stream ended")
+			}
+			return fmt.Errorf("failed to read ffmpeg output: %w", readErr)
+		}
+	}
+}
+
+// ffmpegArgs 构建ffmpeg参数，aac仅转封装为ADTS，mp3则转码
+func (r *relay) ffmpegArgs(streamURL string) []string {
+	args := []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-user_agent", r.manager.userAgent,
+		"-headers", "Referer: " + r.manager.referer + "\r\n",
+		"-i", streamURL,
+		"-vn",
+	}
+
+	if r.manager.cfg.Format == "mp3" {
+		bitrate := r.manager.cfg.Bitrate
+		if bitrate <= 0 {
+			bitrate = 128
+		}
+		args = append(args,
+			"-acodec", "libmp3lame",
+			"-ab", strconv.Itoa(bitrate)+"k",
+			"-f", "mp3",
+		)
+	} else {
+		args = append(args,
+			"-acodec", "copy",
+			"-f", "adts",
+		)
+	}
+
+	return append(args, "pipe:1")
+}
+
+// broadcast 将数据块发送给所有听众，缓冲已满的听众被断开
+// 丢弃数据块会使听众收到残缺的音频帧，断开后客户端可以重新连接
+func (r *relay) broadcast(chunk []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for sub := range r.subscribers {
+		select {
+		case sub.ch <- chunk:
+		default:
+			delete(r.subscribers, sub)
+			sub.closed = true
+			close(sub.ch)
+			logrus.Warnf("Live relay for room %d disconnected a slow listener", r.roomID)
+		}
+	}
+}
+
+// closeAll 上游结束时关闭所有听众的通道
+func (r *relay) closeAll() {
+	m := r.manager
+	m.mu.Lock()
+	if m.relays[r.roomID] == r {
+		delete(m.relays, r.roomID)
+	}
+	m.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for sub := range r.subscribers {
+		if !sub.closed {
+			sub.closed = true
+			close(sub.ch)
+		}
+	}
+}
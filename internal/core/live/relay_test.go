@@ -0,0 +1,342 @@
+package live
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/config"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testShortID = 1
+	testRoomID  = 1000
+)
+
+// TestMain 以LIVE_TEST_FFMPEG=1启动时作为模拟的ffmpeg进程运行
+func TestMain(m *testing.M) {
+	if os.Getenv("LIVE_TEST_FFMPEG") == "1" {
+		os.Exit(fakeFFmpeg(os.Args[1:]))
+	}
+	ffmpegCommand = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		cmd := exec.CommandContext(ctx, os.Args[0], args...)
+		cmd.Env = append(os.Environ(), "LIVE_TEST_FFMPEG=1")
+		return cmd
+	}
+	os.Exit(m.Run())
+}
+
+// fakeFFmpeg 按ffmpeg参数中的 -i、-user_agent、-headers 拉取直播流并原样写到标准输出
+func fakeFFmpeg(args []string) int {
+	var input, userAgent, headers string
+	for i := 0; i+1 < len(args); i++ {
+		switch args[i] {
+		case "-i":
+			input = args[i+1]
+		case "-user_agent":
+			userAgent = args[i+1]
+		case "-headers":
+			headers = args[i+1]
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, input, nil)
+	if err != nil {
+		return 1
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if referer, ok := strings.CutPrefix(strings.TrimSpace(headers), "Referer: "); ok {
+		req.Header.Set("Referer", referer)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 1
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 1
+	}
+	if _, err := io.Copy(os.Stdout, resp.Body); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// fakeOrigin 模拟直播API与直播流，stream决定第n个上游连接 (从1开始) 的内容
+type fakeOrigin struct {
+	server       *httptest.Server
+	stream       func(n int, w http.ResponseWriter, r *http.Request)
+	offlineAfter int32 // 已建立的连接数达到该值后直播间下播，0表示一直在播
+
+	connections int32 // 已建立的上游连接数
+	active      int32 // 当前打开的上游连接数
+}
+
+func newFakeOrigin(t *testing.T, offlineAfter int, stream func(n int, w http.ResponseWriter, r *http.Request)) *fakeOrigin {
+	o := &fakeOrigin{stream: stream, offlineAfter: int32(offlineAfter)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/room/v1/Room/room_init", func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		if id != fmt.Sprint(testShortID) && id != fmt.Sprint(testRoomID) {
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 60004, "message": "直播间不存在"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{"room_id": testRoomID, "short_id": testShortID, "live_status": liveStatusLive},
+		})
+	})
+	mux.HandleFunc("/xlive/web-room/v2/index/getRoomPlayInfo", func(w http.ResponseWriter, r *http.Request) {
+		if o.offlineAfter > 0 && atomic.LoadInt32(&o.connections) >= o.offlineAfter {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 0,
+				"data": map[string]interface{}{"room_id": testRoomID, "live_status": 0},
+			})
+			return
+		}
+		codec := map[string]interface{}{
+			"codec_name": "avc",
+			"base_url":   fmt.Sprintf("/live/%d.flv", testRoomID),
+			"url_info":   []map[string]string{{"host": o.server.URL, "extra": "?expires=1"}},
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{
+				"room_id":     testRoomID,
+				"live_status": liveStatusLive,
+				"playurl_info": map[string]interface{}{"playurl": map[string]interface{}{"stream": []interface{}{
+					map[string]interface{}{"protocol_name": "http_stream", "format": []interface{}{
+						map[string]interface{}{"format_name": "flv", "codec": []interface{}{codec}},
+					}},
+				}}},
+			},
+		})
+	})
+	mux.HandleFunc(fmt.Sprintf("/live/%d.flv", testRoomID), func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Referer") != "https://live.bilibili.com" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		n := atomic.AddInt32(&o.connections, 1)
+		atomic.AddInt32(&o.active, 1)
+		defer atomic.AddInt32(&o.active, -1)
+		o.stream(int(n), w, r)
+	})
+
+	o.server = httptest.NewServer(mux)
+	t.Cleanup(o.server.Close)
+	return o
+}
+
+func (o *fakeOrigin) manager(maxReconnects, maxListeners int) *Manager {
+	return NewManager(config.LiveConfig{
+		APIBase:        o.server.URL,
+		Format:         "aac",
+		ReconnectDelay: 10 * time.Millisecond,
+		MaxReconnects:  maxReconnects,
+		MaxListeners:   maxListeners,
+	}, "test-agent", "https://live.bilibili.com")
+}
+
+// writeChunks 写入count个数据块，每块之后刷新并等待pause，客户端断开时提前返回
+func writeChunks(w http.ResponseWriter, r *http.Request, chunk []byte, count int, pause time.Duration) {
+	for i := 0; count <= 0 || i < count; i++ {
+		if _, err := w.Write(chunk); err != nil {
+			return
+		}
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(pause):
+		}
+	}
+}
+
+// collect 读取听众的全部数据直到通道关闭
+func collect(t *testing.T, sub *Subscriber) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case chunk, ok := <-sub.C():
+			if !ok {
+				return buf.Bytes()
+			}
+			buf.Write(chunk)
+		case <-timeout:
+			t.Fatalf("stream did not end, received %d bytes", buf.Len())
+		}
+	}
+}
+
+// receive 等待听众收到至少一个数据块
+func receive(t *testing.T, sub *Subscriber) {
+	t.Helper()
+	select {
+	case _, ok := <-sub.C():
+		if !ok {
+			t.Fatal("stream closed unexpectedly")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no data received")
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// 同一直播间 (短号与真实房间号) 的听众共享一个上游连接，最后一个听众离开时断开
+func TestRelaySharedUpstream(t *testing.T) {
+	origin := newFakeOrigin(t, 0, func(n int, w http.ResponseWriter, r *http.Request) {
+		writeChunks(w, r, bytes.Repeat([]byte{'a'}, 1024), 0, 5*time.Millisecond)
+	})
+	m := origin.manager(3, 2)
+
+	a, err := m.Subscribe(testShortID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := m.Subscribe(testRoomID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Subscribe(testRoomID); !errors.Is(err, ErrTooManyListeners) {
+		t.Errorf("third listener: err = %v, want ErrTooManyListeners", err)
+	}
+	if _, err := m.Subscribe(999); !errors.Is(err, ErrRoomNotFound) {
+		t.Errorf("unknown room: err = %v, want ErrRoomNotFound", err)
+	}
+
+	receive(t, a)
+	receive(t, b)
+	if stats := m.Stats(); len(stats) != 1 || stats[testRoomID] != 2 {
+		t.Errorf("Stats = %v, want map[%d:2]", stats, testRoomID)
+	}
+
+	a.Close()
+	receive(t, b)
+
+	b.Close()
+	if stats := m.Stats(); len(stats) != 0 {
+		t.Errorf("Stats after all listeners left = %v", stats)
+	}
+	waitFor(t, "upstream to disconnect", func() bool { return atomic.LoadInt32(&origin.active) == 0 })
+	if n := atomic.LoadInt32(&origin.connections); n != 1 {
+		t.Errorf("upstream connections = %d, want 1", n)
+	}
+}
+
+// 上游断开后重新解析地址并重连，直播间下播后结束
+func TestRelayReconnect(t *testing.T) {
+	origin := newFakeOrigin(t, 2, func(n int, w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "connection-%d;", n)
+	})
+	m := origin.manager(3, 0)
+
+	sub, err := m.Subscribe(testRoomID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	if got := string(collect(t, sub)); got != "connection-1;connection-2;" {
+		t.Errorf("received %q", got)
+	}
+	if n := atomic.LoadInt32(&origin.connections); n != 2 {
+		t.Errorf("upstream connections = %d, want 2", n)
+	}
+	waitFor(t, "relay to stop", func() bool { return len(m.Stats()) == 0 })
+}
+
+// 连续重连失败超过上限后放弃并关闭听众
+func TestRelayGivesUpAfterMaxReconnects(t *testing.T) {
+	origin := newFakeOrigin(t, 0, func(n int, w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	})
+	m := origin.manager(2, 0)
+
+	sub, err := m.Subscribe(testRoomID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	if got := collect(t, sub); len(got) != 0 {
+		t.Errorf("received %d bytes from failing upstream", len(got))
+	}
+	if n := atomic.LoadInt32(&origin.connections); n != 3 {
+		t.Errorf("upstream connections = %d, want 3 (1 + 2 reconnects)", n)
+	}
+}
+
+// 不读取数据的听众缓冲满后被断开，其他听众不受影响并收到完整数据
+func TestRelayDropsSlowListener(t *testing.T) {
+	const chunks = 400
+	origin := newFakeOrigin(t, 1, func(n int, w http.ResponseWriter, r *http.Request) {
+		writeChunks(w, r, bytes.Repeat([]byte{'b'}, chunkSize), chunks, time.Millisecond)
+	})
+	m := origin.manager(3, 0)
+
+	fast, err := m.Subscribe(testRoomID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fast.Close()
+	slow, err := m.Subscribe(testRoomID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slow.Close()
+
+	// 快听众在后台持续读取 (collect会调用t.Fatalf，不能在其他goroutine中使用)
+	received := make(chan int, 1)
+	go func() {
+		total := 0
+		for chunk := range fast.C() {
+			total += len(chunk)
+		}
+		received <- total
+	}()
+
+	// 上游仍在发送时慢听众已被移除
+	waitFor(t, "slow listener to be disconnected", func() bool { return m.Stats()[testRoomID] == 1 })
+
+	// 慢听众的通道已关闭，只剩断开前缓冲的连续数据块
+	buffered := 0
+	for range slow.C() {
+		buffered++
+	}
+	if buffered != subscriberBuffer {
+		t.Errorf("slow listener buffered %d chunks, want %d", buffered, subscriberBuffer)
+	}
+
+	select {
+	case got := <-received:
+		if got != chunks*chunkSize {
+			t.Errorf("fast listener received %d bytes, want %d", got, chunks*chunkSize)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("fast listener stream did not end")
+	}
+}
@@ -0,0 +1,172 @@
+package live
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrRoomNotFound 直播间不存在
+	ErrRoomNotFound = errors.New("live room not found")
+	// ErrRoomNotLive 直播间未开播
+	ErrRoomNotLive = errors.New("live room is not streaming")
+)
+
+// RoomInitResponse 直播间初始化信息响应
+type RoomInitResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		RoomID     int64 `json:"room_id"`
+		ShortID    int64 `json:"short_id"`
+		UID        int64 `json:"uid"`
+		LiveStatus int   `json:"live_status"` // 0:未开播 1:直播中 2:轮播中
+	} `json:"data"`
+}
+
+// RoomPlayInfoResponse 直播间播放信息响应
+type RoomPlayInfoResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		RoomID      int64 `json:"room_id"`
+		LiveStatus  int   `json:"live_status"`
+		PlayURLInfo *struct {
+			PlayURL struct {
+				Stream []struct {
+					ProtocolName string `json:"protocol_name"`
+					Format       []struct {
+						FormatName string `json:"format_name"`
+						Codec      []struct {
+							CodecName string `json:"codec_name"`
+							BaseURL   string `json:"base_url"`
+							URLInfo   []struct {
+								Host  string `json:"host"`
+								Extra string `json:"extra"`
+							} `json:"url_info"`
+						} `json:"codec"`
+					} `json:"format"`
+				} `json:"stream"`
+			} `json:"playurl"`
+		} `json:"playurl_info"`
+	} `json:"data"`
+}
+
+const liveStatusLive = 1
+
+// Resolver 直播间播放地址解析器
+type Resolver struct {
+	apiBase   string
+	userAgent string
+	referer   string
+	client    *http.Client
+}
+
+// NewResolver 创建直播间解析器
+func NewResolver(apiBase, userAgent, referer string) *Resolver {
+	return &Resolver{
+		apiBase:   strings.TrimRight(apiBase, "/"),
+		userAgent: userAgent,
+		referer:   referer,
+		client: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+// ResolveRoom 将短号转换为真实房间号并检查开播状态
+func (r *Resolver) ResolveRoom(roomID int64) (int64, error) {
+	var resp RoomInitResponse
+	if err := r.getJSON(r.apiBase+"/room/v1/Room/room_init?id="+strconv.FormatInt(roomID, 10), &resp); err != nil {
+		return 0, fmt.Errorf("failed to get room info: %w", err)
+	}
+
+	if resp.Code != 0 || resp.Data.RoomID == 0 {
+		return 0, fmt.Errorf("%w: code=%d, message=%s", ErrRoomNotFound, resp.Code, resp.Message)
+	}
+
+	if resp.Data.LiveStatus != liveStatusLive {
+		return 0, ErrRoomNotLive
+	}
+
+	return resp.Data.RoomID, nil
+}
+
+// ResolveStreamURL 获取直播流地址，优先HTTP-FLV，其次HLS
+func (r *Resolver) ResolveStreamURL(roomID int64) (string, error) {
+	params := url.Values{}
+	params.Set("room_id", strconv.FormatInt(roomID, 10))
+	params.Set("protocol", "0,1")
+	params.Set("format", "0,1,2")
+	params.Set("codec", "0")
+	params.Set("qn", "10000")
+	params.Set("platform", "web")
+
+	var resp RoomPlayInfoResponse
+	if err := r.getJSON(r.apiBase+"/xlive/web-room/v2/index/getRoomPlayInfo?"+params.Encode(), &resp); err != nil {
+		return "", fmt.Errorf("failed to get room play info: %w", err)
+	}
+
+	if resp.Code != 0 {
+		return "", fmt.Errorf("room play info API returned error: code=%d, message=%s", resp.Code, resp.Message)
+	}
+
+	if resp.Data.LiveStatus != liveStatusLive || resp.Data.PlayURLInfo == nil {
+		return "", ErrRoomNotLive
+	}
+
+	// 按格式优先级挑选第一个可用地址
+	for _, want := range []string{"flv", "ts", "fmp4"} {
+		for _, stream := range resp.Data.PlayURLInfo.PlayURL.Stream {
+			for _, format := range stream.Format {
+				if format.FormatName != want {
+					continue
+				}
+				for _, codec := range format.Codec {
+					if len(codec.URLInfo) == 0 {
+						continue
+					}
+					info := codec.URLInfo[0]
+					return info.Host + codec.BaseURL + info.Extra, nil
+				}
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no live stream found")
+}
+
+// getJSON 请求直播接口并解析JSON响应
+func (r *Resolver) getJSON(apiURL string, v interface{}) error {
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", r.userAgent)
+	req.Header.Set("Referer", r.referer)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get response: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return nil
+}
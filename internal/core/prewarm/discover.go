@@ -0,0 +1,240 @@
+package prewarm
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxDocumentSize 单个页面或sitemap的最大读取字节数
+const maxDocumentSize = 10 << 20
+
+var (
+	// bvidPattern 文本中的BV号，匹配视频链接、播放器嵌入 (bvid=) 及纯文本
+	bvidPattern = regexp.MustCompile(`\bBV[0-9A-Za-z]{10}\b`)
+	// shortLinkPattern b23.tv短链接，需要请求后从跳转地址中取得BV号
+	shortLinkPattern = regexp.MustCompile(`(?:https?:)?//b23\.tv/[0-9A-Za-z]+`)
+)
+
+// sitemapDocument sitemap (urlset) 或 sitemap索引 (sitemapindex)
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// Discoverer 从BV号列表、sitemap、RSS/Atom或HTML页面中查找B站视频
+type Discoverer struct {
+	client    *http.Client
+	userAgent string
+	maxPages  int
+}
+
+// NewDiscoverer 创建链接发现器，maxPages为每个sitemap最多抓取的页面数 (含短链接解析)
+func NewDiscoverer(userAgent string, maxPages int) *Discoverer {
+	return &Discoverer{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			// 短链接不跟随跳转，直接从Location中读取BV号
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if via[0].URL.Host == "b23.tv" {
+					return http.ErrUseLastResponse
+				}
+				if len(via) >= 10 {
+					return fmt.Errorf("stopped after 10 redirects")
+				}
+				return nil
+			},
+		},
+		userAgent: userAgent,
+		maxPages:  maxPages,
+	}
+}
+
+// DiscoverURL 抓取页面并查找其中的视频，sitemap会继续抓取其中列出的页面
+func (d *Discoverer) DiscoverURL(pageURL string) ([]string, error) {
+	content, err := d.fetch(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	return d.Discover(content)
+}
+
+// Discover 查找内容中的视频
+// sitemap抓取其中列出的页面 (sitemap索引展开一层)；RSS/Atom、HTML及纯文本直接从内容中查找，
+// 全文输出的订阅源即可覆盖文章中的链接和嵌入播放器
+func (d *Discoverer) Discover(content []byte) ([]string, error) {
+	found := newOrderedSet()
+	budget := d.maxPages
+	d.scan(content, found, &budget)
+
+	pages, err := d.sitemapPages(content)
+	if err != nil {
+		return found.list(), err
+	}
+	for _, page := range pages {
+		if budget <= 0 {
+			break
+		}
+		budget--
+
+		body, err := d.fetch(page)
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+			continue
+		}
+		d.scan(body, found, &budget)
+	}
+
+	return found.list(), nil
+}
+
+// sitemapPages 返回sitemap中列出的页面，内容不是sitemap时返回nil
+func (d *Discoverer) sitemapPages(content []byte) ([]string, error) {
+	doc, ok := parseSitemap(content)
+	if !ok {
+		return nil, nil
+	}
+
+	var pages []string
+	for _, u := range doc.URLs {
+		pages = append(pages, strings.TrimSpace(u.Loc))
+	}
+	for _, s := range doc.Sitemaps {
+		body, err := d.fetch(strings.TrimSpace(s.Loc))
+		if err != nil {
+			return pages, fmt.Errorf("failed to fetch sitemap %s: %w", s.Loc, err)
+		}
+		if child, ok := parseSitemap(body); ok {
+			for _, u := range child.URLs {
+				pages = append(pages, strings.TrimSpace(u.Loc))
+			}
+		}
+	}
+	return pages, nil
+}
+
+// scan 查找内容中的BV号，并解析b23.tv短链接 (消耗抓取额度)
+func (d *Discoverer) scan(content []byte, found *orderedSet, budget *int) {
+	text := string(content)
+	for _, bvid := range ExtractBVIDs(text) {
+		found.add(bvid)
+	}
+
+	for _, link := range uniqueStrings(shortLinkPattern.FindAllString(text, -1)) {
+		if *budget <= 0 {
+			return
+		}
+		*budget--
+
+		if strings.HasPrefix(link, "//") {
+			link = "https:" + link
+		}
+		bvid, err := d.resolveShortLink(link)
+		if err != nil {
+			fmt.Printf("Warning: failed to resolve short link %s: %v\n", link, err)
+			continue
+		}
+		found.add(bvid)
+	}
+}
+
+// resolveShortLink 请求b23.tv短链接，从跳转地址中取得BV号
+func (d *Discoverer) resolveShortLink(link string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, link, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", d.userAgent)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	bvid := bvidPattern.FindString(resp.Header.Get("Location"))
+	if bvid == "" {
+		return "", fmt.Errorf("no video in redirect target")
+	}
+	return bvid, nil
+}
+
+// fetch 抓取页面内容
+func (d *Discoverer) fetch(pageURL string) ([]byte, error) {
+	if !strings.HasPrefix(pageURL, "http://") && !strings.HasPrefix(pageURL, "https://") {
+		return nil, fmt.Errorf("unsupported url: %s", pageURL)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", d.userAgent)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", pageURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: status %d", pageURL, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize))
+}
+
+// parseSitemap 解析sitemap或sitemap索引
+func parseSitemap(content []byte) (*sitemapDocument, bool) {
+	var doc sitemapDocument
+	if err := xml.Unmarshal(content, &doc); err != nil {
+		return nil, false
+	}
+	if doc.XMLName.Local != "urlset" && doc.XMLName.Local != "sitemapindex" {
+		return nil, false
+	}
+	return &doc, true
+}
+
+// ExtractBVIDs 按出现顺序返回文本中不重复的BV号
+func ExtractBVIDs(text string) []string {
+	return uniqueStrings(bvidPattern.FindAllString(text, -1))
+}
+
+// orderedSet 保持加入顺序的字符串集合
+type orderedSet struct {
+	seen  map[string]bool
+	order []string
+}
+
+func newOrderedSet() *orderedSet {
+	return &orderedSet{seen: make(map[string]bool)}
+}
+
+func (s *orderedSet) add(value string) {
+	if !s.seen[value] {
+		s.seen[value] = true
+		s.order = append(s.order, value)
+	}
+}
+
+func (s *orderedSet) list() []string {
+	return s.order
+}
+
+// uniqueStrings 去除重复项并保持顺序
+func uniqueStrings(values []string) []string {
+	set := newOrderedSet()
+	for _, value := range values {
+		set.add(value)
+	}
+	return set.list()
+}
@@ -0,0 +1,203 @@
+package prewarm
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxTaskHistory 保留的最近任务数
+	maxTaskHistory = 50
+	// maxTaskErrors 每个任务最多记录的错误数
+	maxTaskErrors = 20
+)
+
+// 任务状态
+const (
+	StatusQueued      = "queued"
+	StatusDiscovering = "discovering"
+	StatusRunning     = "running"
+	StatusDone        = "done"
+)
+
+// ErrQueueFull 等待中的任务已达上限
+var ErrQueueFull = errors.New("prewarm queue is full")
+
+// Resolver 解析并缓存一个视频，已缓存时返回cached为true
+type Resolver func(bvid string) (cached bool, err error)
+
+// Request 预热请求
+type Request struct {
+	BVIDs []string // 直接指定的BV号
+	URLs  []string // 需要查找视频的sitemap、RSS/Atom或HTML页面
+}
+
+// Task 预热任务及进度
+type Task struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	Sources    []string   `json:"sources,omitempty"` // 查找视频的页面
+	Total      int        `json:"total"`             // 待预热的视频数
+	Cached     int        `json:"cached"`            // 已在缓存中
+	Parsed     int        `json:"parsed"`            // 本次解析并缓存
+	Failed     int        `json:"failed"`
+	Errors     []string   `json:"errors,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	request Request
+	resolve Resolver
+}
+
+// Warmer 在后台低优先级地预热缓存：单个工作协程按顺序处理任务，
+// 每次实际解析后等待delay，避免与正常请求争抢B站接口和转码资源
+type Warmer struct {
+	discoverer *Discoverer
+	delay      time.Duration
+	queue      chan *Task
+
+	mu     sync.Mutex
+	tasks  []*Task // 最近的任务，新任务在后
+	nextID int
+}
+
+// NewWarmer 创建预热器，queueSize为等待中的任务上限
+func NewWarmer(discoverer *Discoverer, delay time.Duration, queueSize int) *Warmer {
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+	return &Warmer{
+		discoverer: discoverer,
+		delay:      delay,
+		queue:      make(chan *Task, queueSize),
+	}
+}
+
+// Start 启动后台工作协程
+func (w *Warmer) Start() {
+	go func() {
+		for task := range w.queue {
+			w.Run(task)
+		}
+	}()
+}
+
+// Enqueue 创建任务并加入后台队列
+func (w *Warmer) Enqueue(request Request, resolve Resolver) (*Task, error) {
+	task := w.NewTask(request, resolve)
+	select {
+	case w.queue <- task:
+	default:
+		return nil, ErrQueueFull
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tasks = append(w.tasks, task)
+	// 超出maxTaskHistory时丢弃最早的已完成任务
+	for len(w.tasks) > maxTaskHistory && w.tasks[0].Status == StatusDone {
+		w.tasks = w.tasks[1:]
+	}
+	return task.copyLocked(), nil
+}
+
+// NewTask 创建任务，可直接传给Run同步执行
+func (w *Warmer) NewTask(request Request, resolve Resolver) *Task {
+	w.mu.Lock()
+	w.nextID++
+	id := strconv.Itoa(w.nextID)
+	w.mu.Unlock()
+
+	return &Task{
+		ID:        id,
+		Status:    StatusQueued,
+		Sources:   request.URLs,
+		CreatedAt: time.Now(),
+		request:   request,
+		resolve:   resolve,
+	}
+}
+
+// Run 执行任务：先从页面中查找视频，再逐个解析未缓存的视频
+func (w *Warmer) Run(task *Task) {
+	w.update(func() { task.Status = StatusDiscovering })
+
+	found := newOrderedSet()
+	for _, bvid := range task.request.BVIDs {
+		found.add(bvid)
+	}
+	for _, source := range task.request.URLs {
+		bvids, err := w.discoverer.DiscoverURL(source)
+		if err != nil {
+			w.update(func() { task.addError("%s: %v", source, err) })
+		}
+		for _, bvid := range bvids {
+			found.add(bvid)
+		}
+	}
+
+	bvids := found.list()
+	w.update(func() {
+		task.Status = StatusRunning
+		task.Total = len(bvids)
+	})
+
+	for _, bvid := range bvids {
+		cached, err := task.resolve(bvid)
+		w.update(func() {
+			switch {
+			case err != nil:
+				task.Failed++
+				task.addError("%s: %v", bvid, err)
+			case cached:
+				task.Cached++
+			default:
+				task.Parsed++
+			}
+		})
+		if !cached && w.delay > 0 {
+			time.Sleep(w.delay)
+		}
+	}
+
+	w.update(func() {
+		now := time.Now()
+		task.Status = StatusDone
+		task.FinishedAt = &now
+	})
+}
+
+// Tasks 返回最近任务的进度，新任务在前
+func (w *Warmer) Tasks() []*Task {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	tasks := make([]*Task, 0, len(w.tasks))
+	for i := len(w.tasks) - 1; i >= 0; i-- {
+		tasks = append(tasks, w.tasks[i].copyLocked())
+	}
+	return tasks
+}
+
+// update 在锁内修改任务进度
+func (w *Warmer) update(fn func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn()
+}
+
+// copyLocked 复制任务进度，调用方需持有Warmer.mu
+func (t *Task) copyLocked() *Task {
+	copied := *t
+	copied.Errors = append([]string(nil), t.Errors...)
+	return &copied
+}
+
+func (t *Task) addError(format string, args ...interface{}) {
+	if len(t.Errors) < maxTaskErrors {
+		t.Errors = append(t.Errors, fmt.Sprintf(format, args...))
+	}
+}
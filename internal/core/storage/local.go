@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// LocalStorage 本地文件系统存储，文件直接保存在缓存目录中
+type LocalStorage struct {
+	dir string
+}
+
+// NewLocal 创建本地文件系统存储
+func NewLocal(dir string) *LocalStorage {
+	return &LocalStorage{dir: dir}
+}
+
+// Put 将本地文件或目录重命名到存储目录 (临时目录与缓存目录位于同一文件系统)
+// 文件直接重命名覆盖，读取方只会看到完整的旧文件或新文件；
+// 目录 (或文件与目录互相替换) 无法原子覆盖，先将旧条目移入临时目录，新目录就位后再删除旧目录
+func (s *LocalStorage) Put(name, localPath string) error {
+	target := filepath.Join(s.dir, name)
+	existing, err := os.Stat(target)
+	if err != nil {
+		return utils.CommitFile(localPath, target)
+	}
+	source, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+	if !existing.IsDir() && !source.IsDir() {
+		return utils.CommitFile(localPath, target)
+	}
+
+	stagingDir := utils.StagingDir(s.dir)
+	if err := os.MkdirAll(stagingDir, 0755); err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	old, err := os.MkdirTemp(stagingDir, filepath.Base(name)+".old-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	// 移入新建的空目录之下，重命名不会与已存在的目录冲突
+	oldPath := filepath.Join(old, "data")
+	if err := os.Rename(target, oldPath); err != nil {
+		os.Remove(old)
+		return fmt.Errorf("failed to move old %s aside: %w", name, err)
+	}
+	if err := utils.CommitFile(localPath, target); err != nil {
+		// 恢复旧条目，继续提供旧的输出
+		os.Rename(oldPath, target)
+		os.RemoveAll(old)
+		return err
+	}
+	return os.RemoveAll(old)
+}
+
+// Open 读取文件内容
+func (s *LocalStorage) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(s.dir, name))
+}
+
+// Delete 删除文件或目录
+func (s *LocalStorage) Delete(name string) error {
+	if err := os.RemoveAll(filepath.Join(s.dir, name)); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", name, err)
+	}
+	return nil
+}
+
+// Exclusive 缓存目录只由本实例使用
+func (s *LocalStorage) Exclusive() bool {
+	return true
+}
+
+// List 列出缓存目录下的全部条目，跳过临时文件目录
+func (s *LocalStorage) List() ([]ObjectInfo, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	objects := make([]ObjectInfo, 0, len(entries))
+	for _, entry := range entries {
+		if utils.IsHiddenPath(entry.Name()) {
+			continue
+		}
+		info, err := s.Stat(entry.Name())
+		if err != nil {
+			continue
+		}
+		objects = append(objects, *info)
+	}
+	return objects, nil
+}
+
+// URL 经 /static 访问
+func (s *LocalStorage) URL(name string) string {
+	return staticURL(name)
+}
+
+// Handler 提供缓存目录中的文件，不列出目录也不提供临时文件
+func (s *LocalStorage) Handler() http.Handler {
+	return http.FileServer(visibleFileSystem{fs: http.Dir(s.dir)})
+}
+
+// Stat 返回文件或目录的信息，目录时累计其中全部文件的大小
+func (s *LocalStorage) Stat(name string) (*ObjectInfo, error) {
+	path := filepath.Join(s.dir, name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	object := &ObjectInfo{Name: name, Size: info.Size(), ModTime: info.ModTime(), IsDir: info.IsDir()}
+	if info.IsDir() {
+		object.Size = 0
+		filepath.Walk(path, func(_ string, fi os.FileInfo, err error) error {
+			if err == nil && !fi.IsDir() {
+				object.Size += fi.Size()
+				if fi.ModTime().After(object.ModTime) {
+					object.ModTime = fi.ModTime()
+				}
+			}
+			return nil
+		})
+	}
+	return object, nil
+}
+
+// visibleFileSystem 隐藏以"."开头的路径 (如 .staging 临时文件目录)，并禁止列出目录
+type visibleFileSystem struct {
+	fs http.FileSystem
+}
+
+// Open 实现http.FileSystem接口
+func (v visibleFileSystem) Open(name string) (http.File, error) {
+	if utils.IsHiddenPath(name) {
+		return nil, os.ErrNotExist
+	}
+	f, err := v.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return noListFile{f}, nil
+}
+
+// noListFile 不返回目录内容的文件
+type noListFile struct {
+	http.File
+}
+
+// Readdir 禁止列出目录
+func (f noListFile) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, nil
+}
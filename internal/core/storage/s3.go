@@ -0,0 +1,701 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/config"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/utils"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// S3访问链接模式
+const (
+	S3URLPresign = "presign" // 预签名链接，客户端直接从存储下载
+	S3URLProxy   = "proxy"   // 经本服务 /static 转发
+	S3URLPublic  = "public"  // 公开读的存储桶或CDN，使用public_url拼接
+)
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3TimeFormat      = "20060102T150405Z"
+	s3DateFormat      = "20060102"
+
+	// s3StatTTL Stat结果的缓存时间，每次命中缓存都会检查文件是否存在，避免每次都发送HEAD请求
+	s3StatTTL = time.Minute
+	// s3StatCacheSize Stat结果的缓存条目上限，超出时整体清空
+	s3StatCacheSize = 4096
+)
+
+// s3ProxyHeaders 转发到存储的请求头 (断点续传与条件请求)
+var s3ProxyHeaders = []string{"Range", "If-Range", "If-None-Match", "If-Modified-Since"}
+
+// s3ResponseHeaders 转发给客户端的响应头
+var s3ResponseHeaders = []string{"Content-Type", "Content-Length", "Content-Range", "Accept-Ranges", "ETag", "Last-Modified", "Cache-Control"}
+
+// S3Storage S3兼容对象存储 (AWS S3、MinIO、R2等)，使用SigV4签名
+// 设置replica时输出保存在 <root><replica>/ 下，列出与删除只作用于本副本的对象，
+// 各副本的缓存索引 (JSON条目与引用计数) 保存在本地，只对自己的对象负责
+type S3Storage struct {
+	endpoint      *url.URL
+	region        string
+	bucket        string
+	root          string // 全部副本共用的对象键前缀，/static 转发以此为根
+	replica       string // 副本标识，为空时独占root
+	prefix        string // 本副本的对象键前缀
+	accessKey     string
+	secretKey     string
+	pathStyle     bool
+	urlMode       string
+	publicURL     string
+	presignExpiry time.Duration
+	client        *http.Client
+	streamClient  *http.Client // 转发 /static 响应体，不限制总时长，由客户端请求的上下文取消
+
+	statMu sync.Mutex
+	stats  map[string]s3StatEntry // 最近存在的对象，Put/Delete时失效
+}
+
+// s3StatEntry 缓存的Stat结果
+type s3StatEntry struct {
+	info ObjectInfo
+	at   time.Time
+}
+
+// NewS3 创建S3兼容对象存储
+func NewS3(cfg config.S3Config) (*S3Storage, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 storage requires endpoint and bucket")
+	}
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %s", cfg.Endpoint)
+	}
+
+	urlMode := cfg.URLMode
+	if urlMode == "" {
+		urlMode = S3URLPresign
+	}
+	switch urlMode {
+	case S3URLPresign, S3URLProxy:
+	case S3URLPublic:
+		if cfg.PublicURL == "" {
+			return nil, fmt.Errorf("s3 url_mode public requires public_url")
+		}
+	default:
+		return nil, fmt.Errorf("invalid s3 url_mode: %s", urlMode)
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	expiry := cfg.PresignExpiry
+	if expiry <= 0 {
+		expiry = time.Hour
+	}
+	if expiry > 7*24*time.Hour {
+		return nil, fmt.Errorf("s3 presign_expiry must not exceed 168h")
+	}
+
+	root := strings.Trim(cfg.Prefix, "/")
+	if root != "" {
+		root += "/"
+	}
+	replica := strings.Trim(cfg.Replica, "/")
+	if strings.Contains(replica, "/") || utils.IsHiddenPath(replica) {
+		return nil, fmt.Errorf("invalid s3 replica: %s", cfg.Replica)
+	}
+	prefix := root
+	if replica != "" {
+		prefix += replica + "/"
+	}
+
+	return &S3Storage{
+		endpoint:      endpoint,
+		region:        region,
+		bucket:        cfg.Bucket,
+		root:          root,
+		replica:       replica,
+		prefix:        prefix,
+		accessKey:     cfg.AccessKey,
+		secretKey:     cfg.SecretKey,
+		pathStyle:     cfg.PathStyle,
+		urlMode:       urlMode,
+		publicURL:     strings.TrimRight(cfg.PublicURL, "/"),
+		presignExpiry: expiry,
+		client: &http.Client{
+			Timeout: 5 * time.Minute,
+		},
+		streamClient: &http.Client{
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				ResponseHeaderTimeout: 30 * time.Second,
+			},
+		},
+		stats: make(map[string]s3StatEntry),
+	}, nil
+}
+
+// Put 上传本地文件或目录 (逐个上传其中的文件) 后删除本地文件
+func (s *S3Storage) Put(name, localPath string) error {
+	s.forgetStat(name)
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		if err := s.putFile(s.prefix+name, localPath); err != nil {
+			return err
+		}
+		return os.Remove(localPath)
+	}
+
+	// 先删除旧目录中的文件，避免残留上次封装的分片
+	if err := s.Delete(name); err != nil {
+		return err
+	}
+	err = filepath.Walk(localPath, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(localPath, p)
+		if err != nil {
+			return err
+		}
+		return s.putFile(s.prefix+name+"/"+filepath.ToSlash(rel), p)
+	})
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(localPath)
+}
+
+// Open 读取文件内容
+func (s *S3Storage) Open(name string) (io.ReadCloser, error) {
+	resp, err := s.do(http.MethodGet, s.prefix+name, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, s.statusError(resp, name)
+	}
+	return resp.Body, nil
+}
+
+// Stat 返回文件信息，不存在同名对象时按目录 (前缀) 统计
+// 存在的对象在s3StatTTL内复用上次的结果
+func (s *S3Storage) Stat(name string) (*ObjectInfo, error) {
+	s.statMu.Lock()
+	entry, ok := s.stats[name]
+	s.statMu.Unlock()
+	if ok && time.Since(entry.at) < s3StatTTL {
+		info := entry.info
+		return &info, nil
+	}
+
+	info, err := s.stat(name)
+	if err != nil {
+		return nil, err
+	}
+	s.statMu.Lock()
+	if len(s.stats) >= s3StatCacheSize {
+		s.stats = make(map[string]s3StatEntry)
+	}
+	s.stats[name] = s3StatEntry{info: *info, at: time.Now()}
+	s.statMu.Unlock()
+	return info, nil
+}
+
+// forgetStat 对象变化时删除缓存的Stat结果
+func (s *S3Storage) forgetStat(name string) {
+	s.statMu.Lock()
+	delete(s.stats, name)
+	s.statMu.Unlock()
+}
+
+// stat 向存储查询文件信息
+func (s *S3Storage) stat(name string) (*ObjectInfo, error) {
+	resp, err := s.do(http.MethodHead, s.prefix+name, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+		return &ObjectInfo{Name: name, Size: resp.ContentLength, ModTime: modTime}, nil
+	case http.StatusNotFound:
+	default:
+		return nil, fmt.Errorf("s3 head %s returned status %d", name, resp.StatusCode)
+	}
+
+	objects, err := s.listObjects(s.prefix + name + "/")
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, os.ErrNotExist
+	}
+	info := &ObjectInfo{Name: name, IsDir: true}
+	for _, object := range objects {
+		info.Size += object.Size
+		if object.LastModified.After(info.ModTime) {
+			info.ModTime = object.LastModified
+		}
+	}
+	return info, nil
+}
+
+// Delete 删除对象及同名前缀下的全部对象 (HLS目录)
+func (s *S3Storage) Delete(name string) error {
+	s.forgetStat(name)
+	if err := s.deleteObject(s.prefix + name); err != nil {
+		return err
+	}
+
+	objects, err := s.listObjects(s.prefix + name + "/")
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if err := s.deleteObject(object.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Exclusive 设置了replica时本副本独占其前缀
+func (s *S3Storage) Exclusive() bool {
+	return s.replica != ""
+}
+
+// List 列出本副本前缀下的全部条目，子目录中的对象合并为一个目录条目
+func (s *S3Storage) List() ([]ObjectInfo, error) {
+	objects, err := s.listObjects(s.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ObjectInfo
+	index := make(map[string]int)
+	for _, object := range objects {
+		name := strings.TrimPrefix(object.Key, s.prefix)
+		top, _, isDir := strings.Cut(name, "/")
+		if top == "" || utils.IsHiddenPath(top) {
+			continue
+		}
+
+		i, ok := index[top]
+		if !ok {
+			i = len(result)
+			index[top] = i
+			result = append(result, ObjectInfo{Name: top, IsDir: isDir})
+		}
+		result[i].Size += object.Size
+		if object.LastModified.After(result[i].ModTime) {
+			result[i].ModTime = object.LastModified
+		}
+	}
+	return result, nil
+}
+
+// URL 按配置的模式生成访问链接
+// HLS播放列表以相对路径引用分片，预签名模式下目录中的文件改为经 /static 转发
+// 经 /static 转发的路径包含副本标识，负载均衡到任一副本都能读取
+func (s *S3Storage) URL(name string) string {
+	switch {
+	case s.urlMode == S3URLPublic:
+		return s.publicURL + "/" + s3EscapePath(s.prefix+name)
+	case s.urlMode == S3URLPresign && !strings.Contains(name, "/"):
+		return s.presign(s.prefix+name, time.Now())
+	case s.replica != "":
+		return staticURL(s.replica + "/" + name)
+	default:
+		return staticURL(name)
+	}
+}
+
+// Handler 转发 /static 请求到存储，支持Range请求
+// 请求路径相对于全部副本共用的前缀，只读，可以提供其他副本的文件
+func (s *S3Storage) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" || utils.IsHiddenPath(name) {
+			http.NotFound(w, r)
+			return
+		}
+
+		header := make(http.Header)
+		for _, key := range s3ProxyHeaders {
+			if value := r.Header.Get(key); value != "" {
+				header.Set(key, value)
+			}
+		}
+
+		// 响应体可能持续较长时间 (大文件、慢速客户端)，不使用有总超时的client，客户端断开时取消
+		req, err := s.newRequest(r.Method, s.root+name, nil, header, nil, nil)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		resp, err := s.streamClient.Do(req.WithContext(r.Context()))
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+			http.NotFound(w, r)
+			return
+		}
+		for _, key := range s3ResponseHeaders {
+			if value := resp.Header.Get(key); value != "" {
+				w.Header().Set(key, value)
+			}
+		}
+		w.WriteHeader(resp.StatusCode)
+		if r.Method == http.MethodGet {
+			io.Copy(w, resp.Body)
+		}
+	})
+}
+
+// putFile 上传单个文件，内容类型按扩展名设置
+func (s *S3Storage) putFile(key, localPath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// 计算内容摘要用于签名，之后从头读取上传
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %w", localPath, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	header := make(http.Header)
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	payloadHash := hex.EncodeToString(hash.Sum(nil))
+
+	resp, err := s.do(http.MethodPut, key, header, &s3Body{reader: f, size: size}, &payloadHash)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s.statusError(resp, key)
+	}
+	return nil
+}
+
+// deleteObject 删除单个对象，不存在时不报错
+func (s *S3Storage) deleteObject(key string) error {
+	resp, err := s.do(http.MethodDelete, key, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s.statusError(resp, key)
+	}
+	return nil
+}
+
+// s3Object ListObjectsV2结果中的一个对象
+type s3Object struct {
+	Key          string    `xml:"Key"`
+	Size         int64     `xml:"Size"`
+	LastModified time.Time `xml:"LastModified"`
+}
+
+// s3ListResult ListObjectsV2响应
+type s3ListResult struct {
+	Contents              []s3Object `xml:"Contents"`
+	IsTruncated           bool       `xml:"IsTruncated"`
+	NextContinuationToken string     `xml:"NextContinuationToken"`
+}
+
+// listObjects 列出前缀下的全部对象 (自动翻页)
+func (s *S3Storage) listObjects(prefix string) ([]s3Object, error) {
+	var objects []s3Object
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.doQuery(http.MethodGet, "", query, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, s.statusError(resp, prefix)
+		}
+
+		var result s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse s3 list response: %w", err)
+		}
+
+		objects = append(objects, result.Contents...)
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// s3Body 已知长度的请求体
+type s3Body struct {
+	reader io.Reader
+	size   int64
+}
+
+// do 发送签名请求到对象
+func (s *S3Storage) do(method, key string, header http.Header, body *s3Body, payloadHash *string) (*http.Response, error) {
+	return s.doQuery(method, key, nil, header, body, payloadHash)
+}
+
+// doQuery 发送签名请求，key为空时请求存储桶本身
+func (s *S3Storage) doQuery(method, key string, query url.Values, header http.Header, body *s3Body, payloadHash *string) (*http.Response, error) {
+	req, err := s.newRequest(method, key, query, header, body, payloadHash)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// newRequest 创建签名请求
+func (s *S3Storage) newRequest(method, key string, query url.Values, header http.Header, body *s3Body, payloadHash *string) (*http.Request, error) {
+	u := s.objectURL(key)
+	u.RawQuery = s3CanonicalQuery(query)
+
+	var reader io.Reader
+	if body != nil {
+		reader = body.reader
+	}
+	req, err := http.NewRequest(method, u.String(), reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.ContentLength = body.size
+	}
+
+	hash := s3UnsignedPayload
+	if payloadHash != nil {
+		hash = *payloadHash
+	} else if body == nil {
+		sum := sha256.Sum256(nil)
+		hash = hex.EncodeToString(sum[:])
+	}
+	s.sign(req, hash, time.Now())
+	return req, nil
+}
+
+// objectURL 对象的请求地址，path_style时存储桶名在路径中，否则在域名中
+func (s *S3Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	base := strings.TrimRight(s.endpoint.Path, "/")
+	if s.pathStyle {
+		u.Path = base + "/" + s.bucket + "/" + key
+		u.RawPath = base + "/" + s3Escape(s.bucket) + "/" + s3EscapePath(key)
+	} else {
+		u.Host = s.bucket + "." + s.endpoint.Host
+		u.Path = base + "/" + key
+		u.RawPath = base + "/" + s3EscapePath(key)
+	}
+	return &u
+}
+
+// sign 为请求添加SigV4签名头
+func (s *S3Storage) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(s3TimeFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// 签名包含Host与全部x-amz-*头
+	headers := map[string]string{"host": req.URL.Host}
+	for key := range req.Header {
+		lower := strings.ToLower(key)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "range" {
+			headers[lower] = strings.TrimSpace(req.Header.Get(key))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := s.scope(now)
+	signature := s.signature(now, s.stringToSign(amzDate, scope, canonicalRequest))
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.accessKey, scope, signedHeaders, signature))
+}
+
+// presign 生成预签名GET链接
+func (s *S3Storage) presign(key string, now time.Time) string {
+	now = now.UTC()
+	amzDate := now.Format(s3TimeFormat)
+	scope := s.scope(now)
+
+	u := s.objectURL(key)
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(s.presignExpiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	rawQuery := s3CanonicalQuery(query)
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		rawQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+
+	signature := s.signature(now, s.stringToSign(amzDate, scope, canonicalRequest))
+	u.RawQuery = rawQuery + "&X-Amz-Signature=" + signature
+	return u.String()
+}
+
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format(s3DateFormat) + "/" + s.region + "/s3/aws4_request"
+}
+
+func (s *S3Storage) stringToSign(amzDate, scope, canonicalRequest string) string {
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	return strings.Join([]string{s3Algorithm, amzDate, scope, hex.EncodeToString(sum[:])}, "\n")
+}
+
+func (s *S3Storage) signature(now time.Time, stringToSign string) string {
+	key := s3HMAC([]byte("AWS4"+s.secretKey), now.Format(s3DateFormat))
+	key = s3HMAC(key, s.region)
+	key = s3HMAC(key, "s3")
+	key = s3HMAC(key, "aws4_request")
+	return hex.EncodeToString(s3HMAC(key, stringToSign))
+}
+
+// statusError 读取错误响应并关闭，404返回os.ErrNotExist
+func (s *S3Storage) statusError(resp *http.Response, name string) error {
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return os.ErrNotExist
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 request for %s returned status %d: %s", name, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3EscapePath 按SigV4规则编码对象路径，保留"/"
+func s3EscapePath(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = s3Escape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// s3CanonicalQuery 按SigV4规则编码并排序查询参数
+func s3CanonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, s3Escape(key)+"="+s3Escape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape 编码除非保留字符 (A-Z a-z 0-9 - _ . ~) 外的全部字节
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
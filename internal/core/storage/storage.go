@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/core/config"
+	"github.com/hazuki-keatsu/bili-audio-parse-api/internal/models"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ObjectInfo 存储中的一个条目 (文件或HLS目录)
+type ObjectInfo struct {
+	Name    string    // 相对于存储根目录的名称
+	Size    int64     // 字节数，目录为其中全部文件之和
+	ModTime time.Time // 最后修改时间，目录为其中最新的文件
+	IsDir   bool
+}
+
+// Storage 转换输出 (音频、歌词、波形、HLS分片及转换清单) 的存储后端
+// 下载与转码始终在本地临时目录中进行，完成后通过Put移入存储
+type Storage interface {
+	// Put 将本地文件或目录移入存储并覆盖同名条目，成功后localPath不再存在
+	Put(name, localPath string) error
+	// Open 读取文件内容，不存在时返回os.ErrNotExist
+	Open(name string) (io.ReadCloser, error)
+	// Stat 返回文件或目录的信息，不存在时返回os.ErrNotExist
+	Stat(name string) (*ObjectInfo, error)
+	// Delete 删除文件或目录，不存在时不报错
+	Delete(name string) error
+	// List 列出根目录下的全部条目 (不含临时文件)
+	List() ([]ObjectInfo, error)
+	// Exclusive List列出的条目是否全部由本实例的缓存索引管理
+	// 为false时 (如多个副本可能共用的存储桶) 对账不删除未被引用的文件
+	Exclusive() bool
+	// URL 返回客户端访问文件的链接
+	URL(name string) string
+	// Handler 提供 /static 下的文件访问，请求路径相对于存储根目录
+	Handler() http.Handler
+}
+
+// New 根据配置创建存储后端，cacheDir为本地存储目录
+func New(cfg config.StorageConfig, cacheDir string) (Storage, error) {
+	switch cfg.Type {
+	case "", "local":
+		return NewLocal(cacheDir), nil
+	case "s3":
+		return NewS3(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unsupported storage type: %s", cfg.Type)
+	}
+}
+
+// ResolveURLs 根据文件名生成音频及配套文件的访问链接
+// 预签名链接会过期，返回缓存内容前需要重新生成
+func ResolveURLs(store Storage, info *models.AudioInfo) {
+	if info == nil {
+		return
+	}
+	if info.FileName != "" {
+		info.URL = store.URL(info.FileName)
+	}
+	for i := range info.Lyrics {
+		if info.Lyrics[i].LRCFile != "" {
+			info.Lyrics[i].LRCURL = store.URL(info.Lyrics[i].LRCFile)
+		}
+		if info.Lyrics[i].VTTFile != "" {
+			info.Lyrics[i].VTTURL = store.URL(info.Lyrics[i].VTTFile)
+		}
+	}
+	for i := range info.Waveforms {
+		if info.Waveforms[i].FileName != "" {
+			info.Waveforms[i].URL = store.URL(info.Waveforms[i].FileName)
+		}
+	}
+	if info.HLS != nil && info.HLS.Dir != "" {
+		info.HLS.URL = store.URL(info.HLS.Dir + "/" + models.HLSMasterPlaylist)
+	}
+}
+
+// staticURL 经本服务 /static 提供的访问路径
+func staticURL(name string) string {
+	return "/static/" + name
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// APIKey 数据库管理的访问密钥，只保存密钥的SHA-256摘要，明文仅在创建时返回一次
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Name       string     `gorm:"size:100" json:"name"`         // 用途说明，如调用方名称
+	Prefix     string     `gorm:"size:16" json:"prefix"`        // 密钥开头几位，用于辨认
+	KeyHash    string     `gorm:"uniqueIndex;size:64" json:"-"` // 密钥的SHA-256摘要 (十六进制)
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`            // 最近一次使用时间，按分钟更新
+	RevokedAt  *time.Time `gorm:"index" json:"revoked_at,omitempty"` // 吊销时间，吊销后不能再使用
+}
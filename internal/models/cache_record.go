@@ -1,6 +1,8 @@
 package models
 
 import (
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -10,9 +12,36 @@ type CacheRecord struct {
 	CacheKey  string    `gorm:"uniqueIndex;size:255" json:"cache_key"`
 	BVID      string    `gorm:"index;size:20" json:"bvid"`
 	Quality   int       `gorm:"index" json:"quality"`
+	Variant   string    `gorm:"size:100" json:"variant"` // 输出变体 (转码配置、截取片段等)，空表示完整音频
+	Loudness  float64   `json:"loudness"`                // 源音频综合响度 (LUFS)，未测量时为0
+	TruePeak  float64   `json:"true_peak"`               // 源音频真峰值 (dBTP)
 	FilePath  string    `gorm:"size:500" json:"file_path"`
+	FileName  string    `gorm:"index;size:255" json:"file_name"` // 引用的音频文件，对应AudioFile
 	CreatedAt time.Time `json:"created_at"`
 	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+
+	LastAccessAt time.Time `gorm:"index" json:"last_access_at"` // 最近一次命中时间，用于LRU淘汰和滑动过期
+	HitCount     int       `gorm:"index" json:"hit_count"`      // 命中次数，用于LFU淘汰
+	Pinned       bool      `gorm:"index" json:"pinned"`         // 固定的条目不会过期，也不会被淘汰
+
+	Page       int    `json:"page"`                   // 分P序号，0表示默认分P
+	Account    string `gorm:"size:20" json:"account"` // 解析时的账号等级
+	KeyVersion int    `json:"key_version"`            // 缓存键格式版本，旧版本条目为0
+	CID        int64  `gorm:"index" json:"cid"`       // 实际解析的分P cid，音频区为0
+	Stream     int    `json:"stream"`                 // 实际选中的音频流编号
+}
+
+// AccountAnonymous 未登录 (匿名访问) 的账号等级
+const AccountAnonymous = "anonymous"
+
+// AudioFile 音频输出文件的引用计数，多个缓存记录可共用同一文件
+type AudioFile struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	FileName  string    `gorm:"uniqueIndex;size:255" json:"file_name"`
+	Size      int64     `json:"size"`
+	RefCount  int       `json:"ref_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // RequestLog 请求日志模型
@@ -30,8 +59,8 @@ type RequestLog struct {
 
 // AudioInfo 音频信息结构
 type AudioInfo struct {
-	URL         string `json:"url"`          // 本地音频文件链接
-	OriginalURL string `json:"original_url"` // 原始B站链接
+	URL         string `json:"url"`          // 音频文件链接 (由存储后端生成)
+	OriginalURL string `json:"original_url"` // 原始B站链接，已过期时为空
 	Format      string `json:"format"`       // 格式 (mp3)
 	Bitrate     int    `json:"bitrate"`      // 比特率
 	Duration    int    `json:"duration"`     // 时长(秒)
@@ -39,4 +68,103 @@ type AudioInfo struct {
 	Size        int64  `json:"size"`         // 文件大小
 	FileName    string `json:"file_name"`    // 本地文件名
 	Expiring    int64  `json:"expiring"`     // 过期时间（秒），-1表示永不过期
+
+	OriginalURLExpires int64 `json:"original_url_expires,omitempty"` // 原始链接过期时间 (Unix秒)，0表示未知
+	CID                int64 `json:"cid,omitempty"`                  // 分P的cid (视频与番剧)
+
+	Title    string `json:"title,omitempty"`     // 标题
+	Artist   string `json:"artist,omitempty"`    // 作者 (视频为UP主，音频区为歌手)
+	Cover    string `json:"cover,omitempty"`     // 封面
+	LyricURL string `json:"lyric_url,omitempty"` // 歌词文件链接 (音频区)
+
+	Lyrics   []LyricTrack `json:"lyrics,omitempty"`   // CC字幕转换的歌词
+	Chapters []Chapter    `json:"chapters,omitempty"` // 章节 (视频分段看点)
+	Loudness *Loudness    `json:"loudness,omitempty"` // 响度测量结果
+
+	Waveforms []Waveform `json:"waveforms,omitempty"` // 各分辨率的波形峰值文件
+	HLS       *HLS       `json:"hls,omitempty"`       // HLS自适应码率流
+}
+
+// HLSMasterPlaylist HLS主播放列表文件名
+const HLSMasterPlaylist = "master.m3u8"
+
+// HLS 封装输出的HLS流
+type HLS struct {
+	URL         string `json:"url"`          // 主播放列表链接 (master.m3u8)
+	Dir         string `json:"dir"`          // 缓存目录中的HLS子目录名
+	SegmentType string `json:"segment_type"` // 分片格式 fmp4 / ts
+	Bitrates    []int  `json:"bitrates"`     // 各档AAC码率(kbps)
+}
+
+// Waveform 一个分辨率的波形峰值文件 (audiowaveform兼容JSON)
+type Waveform struct {
+	SamplesPerPixel int    `json:"samples_per_pixel"` // 每像素采样数
+	SampleRate      int    `json:"sample_rate"`       // 采样率
+	Length          int    `json:"length"`            // 峰值点数
+	URL             string `json:"url"`               // 波形文件链接
+	FileName        string `json:"file_name"`         // 波形本地文件名
+}
+
+// Loudness EBU R128响度测量结果及ReplayGain信息
+type Loudness struct {
+	Integrated float64 `json:"integrated"` // 源音频综合响度 (LUFS)
+	TruePeak   float64 `json:"true_peak"`  // 源音频真峰值 (dBTP)
+	Range      float64 `json:"range"`      // 源音频响度范围 (LU)
+	Normalized bool    `json:"normalized"` // 输出文件是否已做响度标准化
+	TrackGain  float64 `json:"track_gain"` // 输出文件的ReplayGain增益 (dB)
+	TrackPeak  float64 `json:"track_peak"` // 输出文件的ReplayGain峰值 (线性)
+}
+
+// Chapter 音频章节
+type Chapter struct {
+	Title string `json:"title"` // 章节标题
+	Start int    `json:"start"` // 开始时间(秒)
+	End   int    `json:"end"`   // 结束时间(秒)
+}
+
+// LyricTrack 一条字幕轨道转换后的歌词文件
+type LyricTrack struct {
+	Lang    string `json:"lang"`     // 语言代码
+	LangDoc string `json:"lang_doc"` // 语言名称
+	AI      bool   `json:"ai"`       // 是否为AI生成字幕
+	LRCURL  string `json:"lrc_url"`  // LRC歌词链接
+	VTTURL  string `json:"vtt_url"`  // WebVTT字幕链接
+	LRCFile string `json:"lrc_file"` // LRC本地文件名
+	VTTFile string `json:"vtt_file"` // WebVTT本地文件名
+}
+
+// Files 返回该音频在缓存目录中关联的全部本地文件名 (HLS为子目录)
+func (a *AudioInfo) Files() []string {
+	var files []string
+	if a.FileName != "" {
+		files = append(files, a.FileName)
+	}
+	for _, lyric := range a.Lyrics {
+		if lyric.LRCFile != "" {
+			files = append(files, lyric.LRCFile)
+		}
+		if lyric.VTTFile != "" {
+			files = append(files, lyric.VTTFile)
+		}
+	}
+	for _, waveform := range a.Waveforms {
+		if waveform.FileName != "" {
+			files = append(files, waveform.FileName)
+		}
+	}
+	if a.HLS != nil && a.HLS.Dir != "" {
+		files = append(files, a.HLS.Dir)
+	}
+	if manifest := a.ManifestFile(); manifest != "" {
+		files = append(files, manifest)
+	}
+	return files
+}
+
+// ManifestFile 转换结果清单的文件名，与音频文件同名，用于复用已存在的输出
+func (a *AudioInfo) ManifestFile() string {
+	if a.FileName == "" {
+		return ""
+	}
+	return strings.TrimSuffix(a.FileName, filepath.Ext(a.FileName)) + ".meta.json"
 }
@@ -0,0 +1,43 @@
+package models
+
+// 播放列表来源类型
+const (
+	PlaylistTypeSeason   = "season"   // 合集 (ugc_season)
+	PlaylistTypeSeries   = "series"   // 系列
+	PlaylistTypeFavorite = "favorite" // 公开收藏夹
+	PlaylistTypeMenu     = "menu"     // 音频区歌单 (am号)
+	PlaylistTypeBangumi  = "bangumi"  // 番剧/影视剧集 (ss号)
+)
+
+// Track 播放列表中的单个曲目
+type Track struct {
+	Index    int    `json:"index"`            // 在列表中的序号 (从1开始)
+	BVID     string `json:"bvid"`             // BV号
+	AID      int64  `json:"aid"`              // AV号
+	SID      int64  `json:"sid,omitempty"`    // 音频区歌曲ID (au号)
+	EpID     int64  `json:"ep_id,omitempty"`  // 番剧单集ID (ep号)
+	CID      int64  `json:"cid,omitempty"`    // 分P的CID (部分来源不提供)
+	Title    string `json:"title"`            // 标题
+	Artist   string `json:"artist,omitempty"` // 歌手/作者 (音频区)
+	Cover    string `json:"cover"`            // 封面
+	Duration int    `json:"duration"`         // 时长(秒)
+}
+
+// PlaylistOwner 播放列表所有者
+type PlaylistOwner struct {
+	MID  int64  `json:"mid"`            // 用户ID
+	Name string `json:"name,omitempty"` // 用户名
+	Face string `json:"face,omitempty"` // 头像
+}
+
+// Playlist 播放列表 (合集、系列等)
+type Playlist struct {
+	Type        string         `json:"type"`            // 来源类型
+	ID          int64          `json:"id"`              // 来源ID
+	Title       string         `json:"title"`           // 标题
+	Cover       string         `json:"cover"`           // 封面
+	Description string         `json:"description"`     // 简介
+	Owner       *PlaylistOwner `json:"owner,omitempty"` // 所有者
+	Total       int            `json:"total"`           // 曲目总数
+	Tracks      []Track        `json:"tracks"`          // 有序曲目列表
+}
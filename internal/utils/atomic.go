@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StagingDirName 缓存目录下的临时文件目录，写入完成后再移动到最终位置
+const StagingDirName = ".staging"
+
+// StagingDir 返回缓存目录对应的临时文件目录
+func StagingDir(cacheDir string) string {
+	return filepath.Join(cacheDir, StagingDirName)
+}
+
+// IsHiddenPath 路径中是否包含以"."开头的部分 (临时文件目录等不应对外提供)
+func IsHiddenPath(name string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(name), "/") {
+		if strings.HasPrefix(part, ".") && part != "." && part != ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteFileAtomic 先写入临时目录中的临时文件并同步到磁盘，再重命名到目标路径
+// 读取方只会看到完整的旧文件或新文件，崩溃时残留的临时文件由启动清理删除
+func WriteFileAtomic(stagingDir, path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(stagingDir, 0755); err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(stagingDir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to chmod temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	return CommitFile(tmpPath, path)
+}
+
+// CommitFile 将已写完的临时文件 (或目录) 同步到磁盘后重命名到目标路径
+func CommitFile(tmpPath, path string) error {
+	if err := syncPath(tmpPath); err != nil {
+		os.RemoveAll(tmpPath)
+		return fmt.Errorf("failed to sync %s: %w", tmpPath, err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.RemoveAll(tmpPath)
+		return fmt.Errorf("failed to move %s into place: %w", path, err)
+	}
+
+	// 同步目标目录，确保重命名本身已持久化 (部分平台不支持同步目录，忽略错误)
+	syncFile(filepath.Dir(path))
+	return nil
+}
+
+// syncPath 同步文件内容到磁盘，目录时同步其中的全部文件及目录本身
+func syncPath(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				if err := syncFile(filepath.Join(path, entry.Name())); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return syncFile(path)
+}
+
+func syncFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
@@ -1,8 +1,14 @@
 package utils
 
 import (
+	"fmt"
+	"math"
 	"net/http"
+	"net/url"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -40,3 +46,48 @@ func IsValidBVID(bvid string) bool {
 	matched, _ := regexp.MatchString(`^BV[a-zA-Z0-9]{10}$`, bvid)
 	return matched
 }
+
+// ParseTimestamp 解析时间点，支持秒数 (90.5) 及 mm:ss / hh:mm:ss 格式 (1:30.5)
+func ParseTimestamp(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp: %s", value)
+	}
+
+	var seconds float64
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 || math.IsNaN(n) || math.IsInf(n, 0) {
+			return 0, fmt.Errorf("invalid timestamp: %s", value)
+		}
+		// 除最后一段外必须为整数且小于60 (最高位除外)
+		if i < len(parts)-1 && n != float64(int(n)) {
+			return 0, fmt.Errorf("invalid timestamp: %s", value)
+		}
+		if i > 0 && n >= 60 {
+			return 0, fmt.Errorf("invalid timestamp: %s", value)
+		}
+		seconds = seconds*60 + n
+	}
+
+	return seconds, nil
+}
+
+// URLDeadline 解析B站CDN签名链接中的deadline参数 (Unix秒)，链接在该时间后失效
+// 没有deadline参数或无法解析时返回零值
+func URLDeadline(rawURL string) time.Time {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return time.Time{}
+	}
+	deadline, err := strconv.ParseInt(u.Query().Get("deadline"), 10, 64)
+	if err != nil || deadline <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(deadline, 0)
+}